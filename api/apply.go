@@ -0,0 +1,20 @@
+package api
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Apply applies the given apply configuration using server-side apply.
+// Default annotations are only set if obj implements [client.Object], which
+// is the case for apply configurations created from unstructured objects with
+// [client.ApplyConfigurationFromUnstructured].
+func (c *Client) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+	if o, ok := obj.(client.Object); ok && c.defaultAnnotations != nil {
+		o.SetAnnotations(c.annotations(o))
+	}
+
+	return c.WithWatch.Apply(ctx, obj, opts...)
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// EventObjectNameField is the field selector of the name of the object
+	// an event involves.
+	EventObjectNameField = "involvedObject.name"
+	// EventObjectKindField is the field selector of the kind of the object
+	// an event involves.
+	EventObjectKindField = "involvedObject.kind"
+)
+
+// EventTime returns the time the event last occurred.
+func EventTime(e corev1.Event) time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"sort"
+	"strings"
+
+	infrastructure "github.com/ninech/apis/infrastructure/v1alpha1"
+	meta "github.com/ninech/apis/meta/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// NineListTypes returns the list types of all nine.ch resources which are
+// registered in the scheme, sorted by kind.
+func NineListTypes(s *runtime.Scheme) []schema.GroupVersionKind {
+	var lists []schema.GroupVersionKind
+	for gvk := range s.AllKnownTypes() {
+		if !strings.HasSuffix(strings.ToLower(gvk.Kind), "list") {
+			continue
+		}
+		if excludeListType(gvk) {
+			continue
+		}
+		if strings.HasSuffix(strings.ToLower(gvk.Group), "nine.ch") {
+			lists = append(lists, gvk)
+		}
+	}
+	// we sort the items to have a predicatable order of types in the output
+	sort.Slice(
+		lists,
+		func(i, j int) bool {
+			return lists[i].Kind < lists[j].Kind
+		},
+	)
+
+	return lists
+}
+
+func excludeListType(gvk schema.GroupVersionKind) bool {
+	// ClusterData is a non-namespaced resource and used to allow
+	// connecting to deplo.io application replicas.
+	if strings.EqualFold(gvk.Kind, infrastructure.ClusterDataKind+"list") &&
+		strings.EqualFold(gvk.Group, infrastructure.Group) {
+		return true
+	}
+	return false
+}
+
+// IsNineOwned returns true if the object is owned by Nine and therefore not
+// managed by the user.
+func IsNineOwned(obj metav1.Object) bool {
+	value, exists := obj.GetLabels()[meta.NineOwnedLabelKey]
+	return exists && value == meta.NineOwnedLabelValue
+}
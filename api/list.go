@@ -13,16 +13,19 @@ import (
 	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type ListOpts struct {
-	clientListOptions []runtimeclient.ListOption
-	searchForName     string
-	allProjects       bool
-	allNamespaces     bool
-	watch             bool
-	watchFunc         WatchFunc
+	labelSelector labels.Selector
+	fieldSelector fields.Selector
+	searchForName string
+	allProjects   bool
+	allNamespaces bool
+	watch         bool
+	watchFunc     WatchFunc
 }
 
 type ListOpt func(opts *ListOpts)
@@ -33,17 +36,61 @@ func MatchName(name string) ListOpt {
 			cmd.searchForName = ""
 			return
 		}
-		cmd.clientListOptions = append(cmd.clientListOptions, runtimeclient.MatchingFields{"metadata.name": name})
+		cmd.fieldSelector = andFields(cmd.fieldSelector, fields.OneTermEqualSelector("metadata.name", name))
 		cmd.searchForName = name
 	}
 }
 
 func MatchLabel(k, v string) ListOpt {
 	return func(cmd *ListOpts) {
-		cmd.clientListOptions = append(cmd.clientListOptions, runtimeclient.MatchingLabels{k: v})
+		cmd.labelSelector = andLabels(cmd.labelSelector, labels.SelectorFromSet(labels.Set{k: v}))
 	}
 }
 
+// MatchLabelSelector only lists objects matching the label selector. It can
+// be combined with other label matching options.
+func MatchLabelSelector(selector labels.Selector) ListOpt {
+	return func(cmd *ListOpts) {
+		cmd.labelSelector = andLabels(cmd.labelSelector, selector)
+	}
+}
+
+// MatchFieldSelector only lists objects matching the field selector. It can
+// be combined with other field matching options like [MatchName].
+func MatchFieldSelector(selector fields.Selector) ListOpt {
+	return func(cmd *ListOpts) {
+		cmd.fieldSelector = andFields(cmd.fieldSelector, selector)
+	}
+}
+
+func andLabels(selector, other labels.Selector) labels.Selector {
+	if selector == nil {
+		return other
+	}
+	requirements, _ := other.Requirements()
+	return selector.Add(requirements...)
+}
+
+func andFields(selector, other fields.Selector) fields.Selector {
+	if selector == nil {
+		return other
+	}
+	return fields.AndSelectors(selector, other)
+}
+
+// clientListOptions returns the options which are passed to the list calls
+// of the client.
+func (opts *ListOpts) clientListOptions() []runtimeclient.ListOption {
+	var result []runtimeclient.ListOption
+	if opts.labelSelector != nil {
+		result = append(result, runtimeclient.MatchingLabelsSelector{Selector: opts.labelSelector})
+	}
+	if opts.fieldSelector != nil {
+		result = append(result, runtimeclient.MatchingFieldsSelector{Selector: opts.fieldSelector})
+	}
+	return result
+}
+
 func AllProjects() ListOpt {
 	return func(cmd *ListOpts) {
 		cmd.allProjects = true
@@ -100,7 +147,7 @@ func (c *Client) ListObjects(ctx context.Context, list runtimeclient.ObjectList,
 	}
 
 	if opts.allNamespaces {
-		if err := c.List(ctx, list, opts.clientListOptions...); err != nil {
+		if err := c.List(ctx, list, opts.clientListOptions()...); err != nil {
 			return fmt.Errorf("error when listing across all namespaces: %w", err)
 		}
 		return nil
@@ -119,8 +166,7 @@ func (c *Client) ListObjects(ctx context.Context, list runtimeclient.ObjectList,
 		// another project, we return an error saying that we found the
 		// named object somewhere else.
 
-		opts.clientListOptions = append(opts.clientListOptions, runtimeclient.InNamespace(c.Project))
-		if err := c.List(ctx, list, opts.clientListOptions...); err != nil {
+		if err := c.List(ctx, list, append(opts.clientListOptions(), runtimeclient.InNamespace(c.Project))...); err != nil {
 			return err
 		}
 		// if we did not search for a specific named object or we
@@ -149,9 +195,8 @@ func (c *Client) ListObjects(ctx context.Context, list runtimeclient.ObjectList,
 
 	for _, proj := range projects {
 		wg.Go(func() error {
-			tempOpts := slices.Clone(opts.clientListOptions)
-			tempList := reflect.New(reflect.TypeOf(list).Elem()).Interface().(runtimeclient.ObjectList)
-			tempList.GetObjectKind().SetGroupVersionKind(list.GetObjectKind().GroupVersionKind())
+			tempOpts := opts.clientListOptions()
+			tempList := newListOf(list)
 			if err := c.List(ctx, tempList, append(tempOpts, runtimeclient.InNamespace(proj.Name))...); err != nil {
 				return fmt.Errorf("error when searching in project %s: %w", proj.Name, err)
 			}
@@ -104,6 +104,95 @@ func (c *Client) QueryRange(ctx context.Context, out output.LogOutput, q Query)
 	return printResult(resp.Data.Result, out)
 }
 
+// DefaultBatchSize is the amount of entries fetched per request when paging
+// through logs. It matches the default max_entries_limit_per_query of Loki.
+const DefaultBatchSize = 5000
+
+// QueryRangeAll queries all logs within the time range of the query by
+// fetching them in batches of batchSize entries. The entries are printed in
+// chronological order as soon as a batch has been fetched, so the limit and
+// direction of the query are ignored. If set, progress is called after each
+// batch with the total amount of printed entries and the time of the last one.
+// Entries are skipped if more of them share a timestamp than fit into a
+// batch, which is reported to warn if set.
+func (c *Client) QueryRangeAll(ctx context.Context, out output.LogOutput, q Query, batchSize int, progress func(total int, last time.Time), warn func(msg string)) error {
+	q.Direction = logproto.FORWARD
+	q.Limit = batchSize
+	total := 0
+	// entries at the timestamp the previous batch ended with, as the next
+	// batch starts at this timestamp again to not miss any entries which
+	// share it.
+	var boundary map[string]struct{}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		resp, err := c.QueryRangeResponse(ctx, q)
+		if err != nil {
+			return err
+		}
+		streams, ok := resp.Data.Result.(loghttp.Streams)
+		if !ok {
+			return fmt.Errorf("unable to print unsupported type: %v", resp.Data.Result.Type())
+		}
+
+		entries := sortedEntries(streams)
+		// the keys need to be computed before printing, as the output
+		// might remove labels from the entries.
+		keys := make([]string, len(entries))
+		for i, e := range entries {
+			keys[i] = e.key()
+		}
+		printed := 0
+		for i, e := range entries {
+			if e.Timestamp.Equal(q.Start) {
+				if _, seen := boundary[keys[i]]; seen {
+					continue
+				}
+			}
+			out.FormatAndPrintln(e.Timestamp, e.labels, 0, e.Line)
+			printed++
+		}
+		total += printed
+		if len(entries) == 0 {
+			return nil
+		}
+		last := entries[len(entries)-1].Timestamp
+		if progress != nil {
+			progress(total, last)
+		}
+		if len(entries) < batchSize {
+			return nil
+		}
+
+		if printed == 0 {
+			// the whole batch consists of entries we have already
+			// printed, which happens if there are more entries with the
+			// same timestamp than fit into a batch. We skip the rest of
+			// them instead of fetching the same batch forever.
+			if warn != nil {
+				warn(fmt.Sprintf(
+					"skipped log lines at %s, more than %d lines share this timestamp",
+					last.Local().Format(time.RFC3339Nano), batchSize,
+				))
+			}
+			q.Start = last.Add(time.Nanosecond)
+			boundary = nil
+			continue
+		}
+		if boundary == nil || !last.Equal(q.Start) {
+			boundary = map[string]struct{}{}
+		}
+		for i, e := range entries {
+			if e.Timestamp.Equal(last) {
+				boundary[keys[i]] = struct{}{}
+			}
+		}
+		q.Start = last
+	}
+}
+
 // QueryRangeResponse queries logs within a specific time range and returns the response.
 func (c *Client) QueryRangeResponse(ctx context.Context, q Query) (*loghttp.QueryResponse, error) {
 	c.refreshToken(ctx)
@@ -131,19 +220,28 @@ type labelEntry struct {
 	labels loghttp.LabelSet
 }
 
+// key identifies the entry within the entries sharing its timestamp.
+func (e labelEntry) key() string {
+	return e.labels.String() + e.Line
+}
+
 func printStream(streams loghttp.Streams, out output.LogOutput) {
-	sortedEntries := []labelEntry{}
+	for _, e := range sortedEntries(streams) {
+		out.FormatAndPrintln(e.Timestamp, e.labels, 0, e.Line)
+	}
+}
+
+// sortedEntries returns the entries of all streams sorted by their timestamp.
+func sortedEntries(streams loghttp.Streams) []labelEntry {
+	entries := []labelEntry{}
 	for _, s := range streams {
 		for _, entry := range s.Entries {
-			sortedEntries = append(sortedEntries, labelEntry{Entry: entry, labels: s.Labels})
+			entries = append(entries, labelEntry{Entry: entry, labels: s.Labels})
 		}
 	}
 
-	sort.Slice(sortedEntries, func(i, j int) bool { return sortedEntries[i].Timestamp.Before(sortedEntries[j].Timestamp) })
-
-	for _, e := range sortedEntries {
-		out.FormatAndPrintln(e.Timestamp, e.labels, 0, e.Line)
-	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	return entries
 }
 
 // TailQuery tails logs using the loki websocket endpoint.
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/grafana/loki/v3/pkg/logcli/output"
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,3 +40,136 @@ func TestClient(t *testing.T) {
 	is.NoError(c.TailQuery(ctx, 0, out, Query{QueryString: "{app=\"test\"}", Limit: 10}))
 	is.Equal(fmt.Sprintf("%s %s\n", expectedTime.Local().Format(time.RFC3339), expectedLine), buf.String())
 }
+
+// pagingFake returns the entries within the requested time range, honoring
+// the limit and direction like Loki does.
+type pagingFake struct {
+	fake
+	entries []loghttp.Entry
+	queries int
+}
+
+func (f *pagingFake) QueryRange(queryStr string, limit int, start, end time.Time, direction logproto.Direction, step, interval time.Duration, quiet bool) (*loghttp.QueryResponse, error) {
+	f.queries++
+	entries := []loghttp.Entry{}
+	for _, e := range f.entries {
+		if len(entries) == limit {
+			break
+		}
+		if e.Timestamp.Before(start) || !e.Timestamp.Before(end) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return &loghttp.QueryResponse{
+		Data: loghttp.QueryResponseData{
+			ResultType: loghttp.ResultTypeStream,
+			Result:     loghttp.Streams{{Labels: loghttp.LabelSet{"a": "b"}, Entries: entries}},
+		},
+	}, nil
+}
+
+func TestQueryRangeAll(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	entries := func(timestamps ...int) []loghttp.Entry {
+		result := []loghttp.Entry{}
+		for i, ts := range timestamps {
+			result = append(result, loghttp.Entry{
+				Timestamp: start.Add(time.Duration(ts) * time.Second),
+				Line:      fmt.Sprintf("line %d", i),
+			})
+		}
+		return result
+	}
+
+	cases := map[string]struct {
+		entries          []loghttp.Entry
+		expectedLines    []int
+		expectedCalls    int
+		expectedWarnings int
+	}{
+		"single batch": {
+			entries:       entries(0, 1, 2),
+			expectedLines: []int{0, 1, 2},
+			expectedCalls: 1,
+		},
+		"no entries": {
+			expectedCalls: 1,
+		},
+		"batch boundary with equal timestamps": {
+			entries:       entries(0, 1, 2, 3, 4, 4, 4, 5, 6, 7, 8, 9),
+			expectedLines: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+			expectedCalls: 3,
+		},
+		"exactly one batch": {
+			entries:       entries(0, 1, 2, 3, 4),
+			expectedLines: []int{0, 1, 2, 3, 4},
+			expectedCalls: 2,
+		},
+		"more equal timestamps than fit into a batch": {
+			// the entries which do not fit into the first batch are
+			// skipped.
+			entries:          entries(0, 0, 0, 0, 0, 0, 0, 1),
+			expectedLines:    []int{0, 1, 2, 3, 4, 7},
+			expectedCalls:    3,
+			expectedWarnings: 1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			f := &pagingFake{entries: tc.entries}
+			c := &Client{Client: f}
+
+			var buf bytes.Buffer
+			out, err := output.NewLogOutput(&buf, "default", &output.LogOutputOptions{
+				NoLabels: true, ColoredOutput: false, Timezone: time.Local,
+			})
+			is.NoError(err)
+
+			total := 0
+			warnings := 0
+			is.NoError(c.QueryRangeAll(t.Context(), out, Query{Start: start, End: start.Add(time.Hour)}, 5, func(printed int, _ time.Time) {
+				total = printed
+			}, func(string) {
+				warnings++
+			}))
+
+			lines := []string{}
+			if buf.Len() > 0 {
+				lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			}
+			is.Len(lines, len(tc.expectedLines))
+			for i, line := range lines {
+				is.True(strings.HasSuffix(line, fmt.Sprintf(" line %d", tc.expectedLines[i])), line)
+			}
+			is.Equal(len(tc.expectedLines), total)
+			is.Equal(tc.expectedCalls, f.queries)
+			is.Equal(tc.expectedWarnings, warnings)
+		})
+	}
+}
+
+func TestPrefixedOutput(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	var buf bytes.Buffer
+	plain, err := NewPlainOutput(&buf, "default", true)
+	is.NoError(err)
+	out := NewPrefixedOutput(plain, "app", false)
+
+	ts := time.Now()
+	out.FormatAndPrintln(ts, loghttp.LabelSet{"app": "web"}, 0, "GET /")
+	out.FormatAndPrintln(ts, loghttp.LabelSet{"app": "worker"}, 0, "job done")
+	out.FormatAndPrintln(ts, loghttp.LabelSet{}, 0, "no app")
+
+	date := ts.Local().Format(time.RFC3339)
+	is.Equal(date+" web | GET /\n"+date+" worker | job done\n"+date+" no app\n", buf.String())
+	is.Equal(3, out.LineCount())
+}
@@ -0,0 +1,206 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/liggitt/tabwriter"
+	"github.com/prometheus/common/model"
+)
+
+// MetricMode is the output mode of the results of metric queries.
+type MetricMode string
+
+const (
+	MetricTable     MetricMode = "default"
+	MetricJSON      MetricMode = "json"
+	MetricSparkline MetricMode = "sparkline"
+	MetricHistogram MetricMode = "histogram"
+)
+
+// Series is a single series of a metric query result.
+type Series struct {
+	Labels map[string]string `json:"labels"`
+	Points []Point           `json:"points"`
+}
+
+// Point is a single value of a series.
+type Point struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// QueryMetrics runs a metric query within a specific time range and returns
+// the resulting series, sorted by their labels.
+func (c *Client) QueryMetrics(ctx context.Context, q Query) ([]Series, error) {
+	resp, err := c.QueryRangeResponse(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	series := []Series{}
+	switch value := resp.Data.Result.(type) {
+	case loghttp.Matrix:
+		for _, stream := range value {
+			s := newSeries(stream.Metric)
+			for _, p := range stream.Values {
+				s.Points = append(s.Points, Point{Timestamp: p.Timestamp.Time(), Value: float64(p.Value)})
+			}
+			series = append(series, s)
+		}
+	case loghttp.Vector:
+		for _, sample := range value {
+			s := newSeries(sample.Metric)
+			s.Points = append(s.Points, Point{Timestamp: sample.Timestamp.Time(), Value: float64(sample.Value)})
+			series = append(series, s)
+		}
+	default:
+		return nil, fmt.Errorf("unable to print unsupported type: %v", resp.Data.Result.Type())
+	}
+
+	slices.SortFunc(series, func(a, b Series) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return series, nil
+}
+
+func newSeries(metric model.Metric) Series {
+	labels := make(map[string]string, len(metric))
+	for k, v := range metric {
+		labels[string(k)] = string(v)
+	}
+	return Series{Labels: labels}
+}
+
+// String returns the labels of the series in the LogQL notation.
+func (s Series) String() string {
+	metric := make(model.Metric, len(s.Labels))
+	for k, v := range s.Labels {
+		metric[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return metric.String()
+}
+
+// fill returns the points of the series with a zero value for every step
+// without a value, as Loki omits steps without any samples.
+func (s Series) fill(step time.Duration) []Point {
+	if step <= 0 || len(s.Points) == 0 {
+		return s.Points
+	}
+	points := []Point{}
+	for _, p := range s.Points {
+		if len(points) > 0 {
+			for ts := points[len(points)-1].Timestamp.Add(step); ts.Before(p.Timestamp); ts = ts.Add(step) {
+				points = append(points, Point{Timestamp: ts})
+			}
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// PrintSeries prints the series in the given mode. Steps without values are
+// shown as zero in the sparkline and histogram modes if the step is set.
+func PrintSeries(w io.Writer, series []Series, mode MetricMode, step time.Duration) error {
+	switch mode {
+	case MetricJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(series)
+	case MetricSparkline:
+		return printSparklines(w, series, step)
+	case MetricHistogram:
+		return printHistograms(w, series, step)
+	case MetricTable, "":
+		return printTable(w, series)
+	}
+	return fmt.Errorf("unsupported metric output %q", mode)
+}
+
+func printTable(w io.Writer, series []Series) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tVALUE\tSERIES")
+	for _, s := range series {
+		for _, p := range s.Points {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", p.Timestamp.Local().Format(time.RFC3339), formatValue(p.Value), s)
+		}
+	}
+	return tw.Flush()
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+func printSparklines(w io.Writer, series []Series, step time.Duration) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, s := range series {
+		points := s.fill(step)
+		lowest, highest, total := summary(points)
+		line := make([]rune, 0, len(points))
+		for _, p := range points {
+			line = append(line, sparks[scale(p.Value, math.Min(lowest, 0), highest, len(sparks)-1)])
+		}
+		fmt.Fprintf(tw, "%s\t%s\tmin %s, max %s, total %s\n",
+			s, string(line), formatValue(lowest), formatValue(highest), formatValue(total),
+		)
+	}
+	return tw.Flush()
+}
+
+// histogramWidth is the amount of characters of the longest bar.
+const histogramWidth = 40
+
+func printHistograms(w io.Writer, series []Series, step time.Duration) error {
+	for i, s := range series {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, s)
+		points := s.fill(step)
+		lowest, highest, _ := summary(points)
+		tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+		for _, p := range points {
+			bar := strings.Repeat("█", scale(p.Value, math.Min(lowest, 0), highest, histogramWidth))
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", p.Timestamp.Local().Format(time.RFC3339), bar, formatValue(p.Value))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summary returns the lowest, highest and total value of the points.
+func summary(points []Point) (float64, float64, float64) {
+	lowest, highest, total := math.Inf(1), math.Inf(-1), 0.0
+	for _, p := range points {
+		lowest = math.Min(lowest, p.Value)
+		highest = math.Max(highest, p.Value)
+		total += p.Value
+	}
+	if len(points) == 0 {
+		return 0, 0, 0
+	}
+	return lowest, highest, total
+}
+
+// scale scales the value between lowest and highest to the range 0 to size.
+// The graphs start at zero unless there are negative values, so lowest
+// should not be above zero.
+func scale(value, lowest, highest float64, size int) int {
+	if highest <= lowest || math.IsNaN(value) {
+		return 0
+	}
+	return int(math.Round((value - lowest) / (highest - lowest) * float64(size)))
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
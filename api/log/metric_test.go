@@ -0,0 +1,135 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// metricFake returns the result of a metric query.
+type metricFake struct {
+	fake
+	result loghttp.ResultValue
+}
+
+func (f metricFake) QueryRange(queryStr string, limit int, start, end time.Time, direction logproto.Direction, step, interval time.Duration, quiet bool) (*loghttp.QueryResponse, error) {
+	return &loghttp.QueryResponse{
+		Data: loghttp.QueryResponseData{ResultType: f.result.Type(), Result: f.result},
+	}, nil
+}
+
+func TestQueryMetrics(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 14, 0, 0, 0, time.UTC)
+	at := func(minutes int) model.Time {
+		return model.TimeFromUnixNano(start.Add(time.Duration(minutes) * time.Minute).UnixNano())
+	}
+	ts := func(minutes int) string {
+		return start.Add(time.Duration(minutes) * time.Minute).Local().Format(time.RFC3339)
+	}
+	// the width of the time column depends on the local timezone
+	header := "TIME" + strings.Repeat(" ", len(ts(0))-1) + "VALUE   SERIES"
+	matrix := loghttp.Matrix{
+		{
+			Metric: model.Metric{"level": "info"},
+			Values: []model.SamplePair{{Timestamp: at(0), Value: 4}, {Timestamp: at(5), Value: 2}},
+		},
+		{
+			// there were no errors at 14:05, so Loki omits the value.
+			Metric: model.Metric{"level": "error"},
+			Values: []model.SamplePair{{Timestamp: at(0), Value: 1}, {Timestamp: at(10), Value: 8}},
+		},
+	}
+
+	cases := map[string]struct {
+		result   loghttp.ResultValue
+		mode     MetricMode
+		expected string
+	}{
+		"table": {
+			result: matrix,
+			mode:   MetricTable,
+			expected: strings.Join([]string{
+				header,
+				ts(0) + "   1       {level=\"error\"}",
+				ts(10) + "   8       {level=\"error\"}",
+				ts(0) + "   4       {level=\"info\"}",
+				ts(5) + "   2       {level=\"info\"}",
+			}, "\n") + "\n",
+		},
+		"vector": {
+			result: loghttp.Vector{{Metric: model.Metric{}, Timestamp: at(0), Value: 3}},
+			mode:   MetricTable,
+			expected: strings.Join([]string{
+				header,
+				ts(0) + "   3       {}",
+			}, "\n") + "\n",
+		},
+		"sparkline": {
+			result: matrix,
+			mode:   MetricSparkline,
+			expected: strings.Join([]string{
+				`{level="error"}   ▂▁█   min 0, max 8, total 9`,
+				`{level="info"}    █▅    min 2, max 4, total 6`,
+			}, "\n") + "\n",
+		},
+		"histogram": {
+			result: loghttp.Matrix{matrix[0]},
+			mode:   MetricHistogram,
+			expected: strings.Join([]string{
+				`{level="info"}`,
+				ts(0) + " " + strings.Repeat("█", 40) + " 4",
+				ts(5) + " " + strings.Repeat("█", 20) + "                     2",
+			}, "\n") + "\n",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			c := &Client{Client: metricFake{result: tc.result}}
+			series, err := c.QueryMetrics(t.Context(), Query{Step: 5 * time.Minute})
+			is.NoError(err)
+
+			buf := &bytes.Buffer{}
+			is.NoError(PrintSeries(buf, series, tc.mode, 5*time.Minute))
+			is.Equal(tc.expected, buf.String())
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		is := require.New(t)
+
+		c := &Client{Client: metricFake{result: matrix}}
+		series, err := c.QueryMetrics(t.Context(), Query{})
+		is.NoError(err)
+
+		buf := &bytes.Buffer{}
+		is.NoError(PrintSeries(buf, series, MetricJSON, 0))
+		result := []Series{}
+		is.NoError(json.Unmarshal(buf.Bytes(), &result))
+		is.Len(result, 2)
+		is.Equal(map[string]string{"level": "error"}, result[0].Labels)
+		is.Equal(8.0, result[0].Points[1].Value)
+		is.True(start.Add(10 * time.Minute).Equal(result[0].Points[1].Timestamp))
+	})
+
+	t.Run("streams", func(t *testing.T) {
+		t.Parallel()
+
+		c := &Client{Client: metricFake{result: loghttp.Streams{}}}
+		_, err := c.QueryMetrics(t.Context(), Query{})
+		require.Error(t, err)
+	})
+}
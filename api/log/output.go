@@ -2,10 +2,12 @@ package log
 
 import (
 	"fmt"
+	"hash/fnv"
 	"io"
 	"os"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/grafana/loki/v3/pkg/logcli/output"
 	"github.com/grafana/loki/v3/pkg/loghttp"
 )
@@ -34,6 +36,40 @@ func (o filteredOutput) LineCount() int {
 	return o.lineCount
 }
 
+type prefixedOutput struct {
+	Output
+	label   string
+	colored bool
+}
+
+// prefixColors are the colors used for the prefixes of lines. The color of
+// a prefix is chosen by its value, so it stays the same between runs.
+var prefixColors = []color.Attribute{
+	color.FgCyan, color.FgGreen, color.FgYellow, color.FgBlue, color.FgMagenta,
+	color.FgHiCyan, color.FgHiGreen, color.FgHiYellow, color.FgHiBlue, color.FgHiMagenta,
+}
+
+func (o prefixedOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	prefix := lbls[o.label]
+	if prefix == "" {
+		o.Output.FormatAndPrintln(ts, lbls, maxLabelsLen, line)
+		return
+	}
+	if o.colored {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(prefix))
+		prefix = color.New(prefixColors[h.Sum32()%uint32(len(prefixColors))]).Sprint(prefix)
+	}
+	o.Output.FormatAndPrintln(ts, lbls, maxLabelsLen, prefix+" | "+line)
+}
+
+// NewPrefixedOutput returns an output which prefixes every line with the
+// value of the given label, e.g. the name of the application when showing
+// the logs of multiple applications at once.
+func NewPrefixedOutput(out Output, label string, colored bool) Output {
+	return prefixedOutput{Output: out, label: label, colored: colored}
+}
+
 type Output interface {
 	output.LogOutput
 	// LineCount returns the amount of lines the output has processed
@@ -45,8 +81,18 @@ func NewStdOut(mode string, noLabels bool, labels ...string) (Output, error) {
 }
 
 func NewOutput(w io.Writer, mode string, noLabels bool, labels ...string) (Output, error) {
+	return newOutput(w, mode, true, noLabels, labels...)
+}
+
+// NewPlainOutput returns an output without colors, e.g. for writing to a
+// file.
+func NewPlainOutput(w io.Writer, mode string, noLabels bool, labels ...string) (Output, error) {
+	return newOutput(w, mode, false, noLabels, labels...)
+}
+
+func newOutput(w io.Writer, mode string, colored, noLabels bool, labels ...string) (Output, error) {
 	out, err := output.NewLogOutput(w, mode, &output.LogOutputOptions{
-		NoLabels: noLabels, ColoredOutput: true, Timezone: time.Local,
+		NoLabels: noLabels, ColoredOutput: colored, Timezone: time.Local,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("unable to create log output: %s", err)
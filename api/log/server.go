@@ -0,0 +1,380 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	legacy "github.com/grafana/loki/v3/pkg/loghttp/legacy"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/util/httpreq"
+	"github.com/grafana/loki/v3/pkg/util/marshal"
+	"github.com/prometheus/prometheus/model/labels"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	queryRangePath = "/loki/api/v1/query_range"
+	tailPath       = "/loki/api/v1/tail"
+)
+
+// Server is an in-process stand-in for the Loki API. It serves the
+// query_range and tail endpoints from the streams pushed to it, so the log
+// commands can be used without the real logging API. Only log queries are
+// supported, metric queries are rejected.
+type Server struct {
+	mu      sync.Mutex
+	streams []Stream
+	tails   map[*tail]struct{}
+}
+
+// Stream is a set of log entries sharing the same labels.
+type Stream struct {
+	Labels  map[string]string
+	Entries []Entry
+}
+
+// Entry is a single log line.
+type Entry struct {
+	Timestamp time.Time
+	Line      string
+}
+
+// fixtureEntry is an entry in a fixture file. The time is either an RFC3339
+// timestamp or a duration relative to the time the fixture is loaded, e.g.
+// -5m.
+type fixtureEntry struct {
+	Time string `json:"time"`
+	Line string `json:"line"`
+}
+
+type fixture struct {
+	Streams []struct {
+		Labels  map[string]string `json:"labels"`
+		Entries []fixtureEntry    `json:"entries"`
+	} `json:"streams"`
+}
+
+type tail struct {
+	mu    sync.Mutex
+	conn  *websocket.Conn
+	query syntax.LogSelectorExpr
+	flags httpreq.EncodingFlags
+}
+
+// NewServer returns a new server serving the given streams.
+func NewServer(streams ...Stream) *Server {
+	return &Server{streams: streams, tails: map[*tail]struct{}{}}
+}
+
+// NewTestServer starts a server for the duration of the test and returns it
+// together with a client using it.
+func NewTestServer(t testing.TB, streams ...Stream) (*Server, *Client) {
+	t.Helper()
+	s := NewServer(streams...)
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		s.DropConnections()
+		ts.Close()
+	})
+
+	c, err := NewClient(ts.URL, nil, "fake", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, c
+}
+
+// LoadFixtures reads streams from the given YAML or JSON files. Directories
+// are searched for fixture files, non-recursively.
+func LoadFixtures(paths ...string) ([]Stream, error) {
+	now := time.Now()
+	streams := []Stream{}
+	for _, path := range paths {
+		files := []string{path}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			files = nil
+			for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+				matches, err := filepath.Glob(filepath.Join(path, pattern))
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, matches...)
+			}
+			slices.Sort(files)
+		}
+
+		for _, file := range files {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read fixture: %w", err)
+			}
+			f := fixture{}
+			if err := yaml.Unmarshal(b, &f); err != nil {
+				return nil, fmt.Errorf("unable to parse fixture %s: %w", file, err)
+			}
+			for _, s := range f.Streams {
+				stream := Stream{Labels: s.Labels}
+				for _, e := range s.Entries {
+					ts, err := fixtureTime(now, e.Time)
+					if err != nil {
+						return nil, fmt.Errorf("invalid entry in fixture %s: %w", file, err)
+					}
+					stream.Entries = append(stream.Entries, Entry{Timestamp: ts, Line: e.Line})
+				}
+				streams = append(streams, stream)
+			}
+		}
+	}
+	return streams, nil
+}
+
+func fixtureTime(now time.Time, value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d), nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time %q is neither RFC3339 nor a duration", value)
+	}
+	return ts, nil
+}
+
+// Push adds the streams to the server and sends the entries to all tail
+// connections with a matching query.
+func (s *Server) Push(streams ...Stream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.streams = append(s.streams, streams...)
+	for t := range s.tails {
+		result := match(streams, t.query, time.Time{}, time.Time{})
+		if len(result) == 0 {
+			continue
+		}
+		// a failed write means the connection is gone, which is noticed
+		// by the reader of the connection.
+		_ = t.write(result)
+	}
+}
+
+// DropConnections closes all tail connections without a close message, like
+// a Loki querier which stops running.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for t := range s.tails {
+		_ = t.conn.UnderlyingConn().Close()
+		delete(s.tails, t)
+	}
+}
+
+// ServeHTTP serves the query_range and tail endpoints.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.URL.Path {
+	case queryRangePath:
+		s.queryRange(w, r)
+	case tailPath:
+		s.tail(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) queryRange(w http.ResponseWriter, r *http.Request) {
+	req, err := loghttp.ParseRangeQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query, err := parseLogQuery(req.Query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	result := match(s.streams, query, req.Start, req.End)
+	s.mu.Unlock()
+
+	result = limitEntries(result, int(req.Limit), req.Direction)
+	if err := marshal.WriteQueryResponseJSON(logqlmodel.Streams(result), nil, stats.Result{}, w, httpreq.ExtractEncodingFlags(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) tail(w http.ResponseWriter, r *http.Request) {
+	req, err := loghttp.ParseTailQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query, err := parseLogQuery(req.Query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	t := &tail{conn: conn, query: query, flags: httpreq.ExtractEncodingFlags(r)}
+	s.mu.Lock()
+	// entries at the start have already been received by the client, as
+	// it reconnects with the timestamp of the last entry.
+	result := match(s.streams, query, req.Start.Add(time.Nanosecond), time.Time{})
+	// like Loki, we send the most recent entries in chronological order.
+	result = limitEntries(limitEntries(result, int(req.Limit), logproto.BACKWARD), 0, logproto.FORWARD)
+	err = t.write(result)
+	if err == nil {
+		s.tails[t] = struct{}{}
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	// we only read to notice when the client closes the connection.
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.tails, t)
+	s.mu.Unlock()
+}
+
+func (t *tail) write(streams []logproto.Stream) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return marshal.WriteTailResponseJSON(
+		legacy.TailResponse{Streams: streams},
+		marshal.NewWebsocketJSONWriter(t.conn),
+		t.flags,
+	)
+}
+
+func parseLogQuery(query string) (syntax.LogSelectorExpr, error) {
+	expr, err := syntax.ParseLogSelector(query, true)
+	if err != nil {
+		if _, metricErr := syntax.ParseSampleExpr(query); metricErr == nil {
+			return nil, errors.New("metric queries are not supported by the fake server")
+		}
+		return nil, err
+	}
+	return expr, nil
+}
+
+// match returns the entries of the streams matching the query within start
+// and end, grouped by their labels after running the pipeline of the query.
+// A zero start or end is unbounded.
+func match(streams []Stream, query syntax.LogSelectorExpr, start, end time.Time) []logproto.Stream {
+	pipeline, err := query.Pipeline()
+	if err != nil {
+		return nil
+	}
+
+	result := []logproto.Stream{}
+	index := map[string]int{}
+	for _, stream := range streams {
+		lbls := labels.FromMap(stream.Labels)
+		if !matchesAll(query, lbls) {
+			continue
+		}
+		sp := pipeline.ForStream(lbls)
+		for _, e := range stream.Entries {
+			if (!start.IsZero() && e.Timestamp.Before(start)) || (!end.IsZero() && !e.Timestamp.Before(end)) {
+				continue
+			}
+			line, resultLabels, ok := sp.ProcessString(e.Timestamp.UnixNano(), e.Line, labels.EmptyLabels())
+			if !ok {
+				continue
+			}
+			key := resultLabels.String()
+			i, exists := index[key]
+			if !exists {
+				i = len(result)
+				index[key] = i
+				result = append(result, logproto.Stream{Labels: key})
+			}
+			result[i].Entries = append(result[i].Entries, logproto.Entry{Timestamp: e.Timestamp, Line: line})
+		}
+	}
+	return result
+}
+
+func matchesAll(query syntax.LogSelectorExpr, lbls labels.Labels) bool {
+	for _, m := range query.Matchers() {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// limitEntries returns at most limit entries of the streams, the first ones for the
+// forward direction or the last ones for the backward direction. The entries
+// of each stream are sorted in the direction.
+func limitEntries(streams []logproto.Stream, limit int, direction logproto.Direction) []logproto.Stream {
+	type labeledEntry struct {
+		logproto.Entry
+		labels string
+	}
+	entries := []labeledEntry{}
+	for _, s := range streams {
+		for _, e := range s.Entries {
+			entries = append(entries, labeledEntry{Entry: e, labels: s.Labels})
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b labeledEntry) int {
+		if direction == logproto.BACKWARD {
+			return b.Timestamp.Compare(a.Timestamp)
+		}
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	result := []logproto.Stream{}
+	index := map[string]int{}
+	for _, e := range entries {
+		i, exists := index[e.labels]
+		if !exists {
+			i = len(result)
+			index[e.labels] = i
+			result = append(result, logproto.Stream{Labels: e.labels})
+		}
+		result[i].Entries = append(result[i].Entries, e.Entry)
+	}
+	slices.SortFunc(result, func(a, b logproto.Stream) int {
+		return strings.Compare(a.Labels, b.Labels)
+	})
+	return result
+}
@@ -0,0 +1,171 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logcli/output"
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerQueryRange(t *testing.T) {
+	t.Parallel()
+
+	streams, err := LoadFixtures("testdata")
+	require.NoError(t, err)
+	_, c := NewTestServer(t, streams...)
+
+	cases := map[string]struct {
+		query         string
+		limit         int
+		direction     logproto.Direction
+		expectedLines []string
+		expectedErr   bool
+	}{
+		"all lines of an app": {
+			query:     `{namespace="dev",app="web"}`,
+			limit:     10,
+			direction: logproto.FORWARD,
+			expectedLines: []string{
+				`level=info msg="starting server" port=8080`,
+				`{"level":"info","request":{"method":"GET","path":"/"},"status":200}`,
+				`{"level":"error","request":{"method":"POST","path":"/login"},"status":500}`,
+				`{"level":"info","request":{"method":"GET","path":"/healthz"},"status":200}`,
+			},
+		},
+		"backward limit returns the latest lines": {
+			query:     `{namespace="dev"}`,
+			limit:     2,
+			direction: logproto.BACKWARD,
+			expectedLines: []string{
+				`{"level":"info","request":{"method":"GET","path":"/healthz"},"status":200}`,
+				"processing job 2",
+			},
+		},
+		"pipeline": {
+			query:     `{namespace="dev",app="web"} != "healthz" | json | request_method="GET"`,
+			limit:     10,
+			direction: logproto.FORWARD,
+			expectedLines: []string{
+				`{"level":"info","request":{"method":"GET","path":"/"},"status":200}`,
+			},
+		},
+		"no match": {
+			query:     `{namespace="prod"}`,
+			limit:     10,
+			direction: logproto.FORWARD,
+		},
+		"metric query": {
+			query:       `count_over_time({namespace="dev"} [5m])`,
+			limit:       10,
+			direction:   logproto.FORWARD,
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			var buf bytes.Buffer
+			out, err := NewPlainOutput(&buf, "raw", true)
+			is.NoError(err)
+
+			err = c.QueryRange(t.Context(), out, Query{
+				QueryString: tc.query,
+				Limit:       tc.limit,
+				Start:       time.Now().Add(-time.Hour),
+				End:         time.Now(),
+				Direction:   tc.direction,
+				Quiet:       true,
+			})
+			if tc.expectedErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+
+			lines := []string{}
+			if buf.Len() > 0 {
+				lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			}
+			is.Equal(len(tc.expectedLines), len(lines), buf.String())
+			for i, line := range tc.expectedLines {
+				is.Equal(line, lines[i])
+			}
+		})
+	}
+}
+
+// chanOutput sends every line it prints to a channel.
+type chanOutput struct {
+	lines chan string
+}
+
+func (o chanOutput) FormatAndPrintln(_ time.Time, _ loghttp.LabelSet, _ int, line string) {
+	o.lines <- line
+}
+
+func (o chanOutput) WithWriter(io.Writer) output.LogOutput {
+	return o
+}
+
+func TestServerTail(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	start := time.Now()
+	s, c := NewTestServer(t, Stream{
+		Labels:  map[string]string{"app": "web"},
+		Entries: []Entry{{Timestamp: start.Add(-time.Minute), Line: "before start"}},
+	})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Second)
+	defer cancel()
+	out := chanOutput{lines: make(chan string, 10)}
+	done := make(chan error)
+	go func() {
+		done <- c.TailQuery(ctx, 0, out, Query{QueryString: `{app="web"}`, Limit: 10, Start: start, Quiet: true})
+	}()
+
+	receive := func() string {
+		select {
+		case line := <-out.lines:
+			return line
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for line")
+			return ""
+		}
+	}
+
+	push := func(app, line string) {
+		s.Push(Stream{
+			Labels:  map[string]string{"app": app},
+			Entries: []Entry{{Timestamp: time.Now(), Line: line}},
+		})
+	}
+
+	push("web", "first")
+	is.Equal("first", receive())
+	push("worker", "other app")
+	push("web", "second")
+	is.Equal("second", receive())
+
+	// the client reconnects and receives the lines it missed in between.
+	s.DropConnections()
+	push("web", "while disconnected")
+	is.Equal("while disconnected", receive())
+	push("web", "after reconnect")
+	is.Equal("after reconnect", receive())
+
+	cancel()
+	is.NoError(<-done)
+	is.Empty(out.lines)
+}
@@ -1,43 +1,61 @@
 package api
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
 
+	management "github.com/ninech/apis/management/v1alpha1"
+	"golang.org/x/sync/errgroup"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type WatchFunc func(list runtimeclient.ObjectList) error
 
-// TODO: all-projects/all-namespaces options don't work as expected. The initial
-// list call is correct but the watch call just happens in the current project.
-// All namespaces is easy to solve but all projects would mean a goroutine/watch
-// per project.
+// watch lists the objects and then watches them for changes, calling the
+// WatchFunc for the initial list and every change. With all-namespaces a
+// single cluster-wide watch is used, with all-projects a watch is opened for
+// every project, which are added and removed as projects come and go.
 func (c *Client) watch(ctx context.Context, list runtimeclient.ObjectList, options ...ListOpt) error {
 	// this is a bit awkward: we need to extract some functional options and
 	// make sure we don't pass the watch option down to c.ListObjects as that
 	// will cause infinite recursion. We do this by executing each opt and
 	// checking the result.
 	newOptions := []ListOpt{}
-	var watchFunc WatchFunc
-	var clientListOptions []runtimeclient.ListOption
+	opts := &ListOpts{}
 	for _, opt := range options {
-		opts := &ListOpts{}
-		opt(opts)
-		if !opts.watch {
+		optOpts := &ListOpts{}
+		opt(optOpts)
+		if !optOpts.watch {
 			newOptions = append(newOptions, opt)
 		}
-		if opts.watchFunc != nil {
-			watchFunc = opts.watchFunc
-		}
-		if opts.clientListOptions != nil {
-			clientListOptions = opts.clientListOptions
+		opt(opts)
+	}
+
+	// the watches are stopped when forwarding ends, e.g. because printing
+	// an event failed.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	mux := newWatchMux(c, list, opts.clientListOptions(), watchBackoff)
+	if opts.allProjects {
+		if err := c.startProjectWatches(ctx, list, opts, mux); err != nil {
+			return err
 		}
+		return mux.forward(ctx, list, opts.watchFunc)
 	}
+
 	// do an initial list call, this is to get immediate output of the current
 	// list if it has any items. After that updates will be streamed in by the
 	// watch. If we would just call watch immediately, the list wouldn't be
@@ -46,44 +64,349 @@ func (c *Client) watch(ctx context.Context, list runtimeclient.ObjectList, optio
 	if err := c.ListObjects(ctx, list, newOptions...); err != nil {
 		return err
 	}
-	items, err := itemsFromObjectList(list)
+	if err := callWithItems(list, opts.watchFunc); err != nil {
+		return err
+	}
+
+	namespace := c.Project
+	if opts.allNamespaces {
+		namespace = metav1.NamespaceAll
+	}
+	// in order to get around the initial list of items, we start the
+	// watch at the resource version of the list.
+	mux.add(ctx, namespace, list.GetResourceVersion())
+
+	return mux.forward(ctx, list, opts.watchFunc)
+}
+
+// startProjectWatches lists the objects in all projects, calls the
+// WatchFunc with the result and then starts a watch per project. The
+// projects are watched as well to start and stop the watches of added and
+// removed projects.
+func (c *Client) startProjectWatches(ctx context.Context, list runtimeclient.ObjectList, opts *ListOpts, mux *watchMux) error {
+	org, err := c.Organization()
 	if err != nil {
 		return err
 	}
-	if items.Len() > 0 {
-		if err := watchFunc(list); err != nil {
+	projects := &management.ProjectList{}
+	if err := c.List(ctx, projects, runtimeclient.InNamespace(org)); err != nil {
+		return fmt.Errorf("error when searching for projects: %w", err)
+	}
+	slices.SortFunc(projects.Items, func(a, b management.Project) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	// every project is listed on its own to know the resource version
+	// from which its watch has to start.
+	projectLists := make([]runtimeclient.ObjectList, len(projects.Items))
+	wg := errgroup.Group{}
+	for i, proj := range projects.Items {
+		wg.Go(func() error {
+			projectList := newListOf(list)
+			if err := c.List(ctx, projectList, append(opts.clientListOptions(), runtimeclient.InNamespace(proj.Name))...); err != nil {
+				return fmt.Errorf("error when searching in project %s: %w", proj.Name, err)
+			}
+			projectLists[i] = projectList
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return err
+	}
+
+	var items []runtime.Object
+	for _, projectList := range projectLists {
+		projectItems, err := meta.ExtractList(projectList)
+		if err != nil {
 			return err
 		}
+		items = append(items, projectItems...)
+	}
+	if err := meta.SetList(list, items); err != nil {
+		return err
 	}
+	if err := callWithItems(list, opts.watchFunc); err != nil {
+		return err
+	}
+
+	for i, proj := range projects.Items {
+		mux.add(ctx, proj.Name, projectLists[i].GetResourceVersion())
+	}
+
+	projectEvents := make(chan watch.Event)
+	go func() {
+		if err := c.watchNamespace(ctx, &management.ProjectList{}, org, projects.ResourceVersion, nil, mux.backoff, projectEvents); err != nil {
+			mux.fail(err)
+		}
+	}()
+	go func() {
+		for {
+			select {
+			case res := <-projectEvents:
+				proj, ok := res.Object.(runtimeclient.Object)
+				if !ok {
+					continue
+				}
+				switch res.Type {
+				case watch.Added:
+					// a new project has no resource version to
+					// start from, so all of its objects are sent.
+					mux.add(ctx, proj.GetName(), "")
+				case watch.Deleted:
+					mux.remove(proj.GetName())
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// watchBackoff is the backoff used to re-establish interrupted watches. The
+// budget of steps is reset whenever the watch makes progress, so only
+// consecutive failures make the watch give up.
+var watchBackoff = wait.Backoff{
+	Steps:    8,
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Cap:      30 * time.Second,
+}
+
+// minWatchDuration is the duration after which a watch which is closed by
+// the API server without any events is considered to have made progress.
+// API servers routinely close watches after a few minutes.
+const minWatchDuration = 10 * time.Second
 
-	wa, err := c.Watch(ctx, list, append(clientListOptions, &runtimeclient.ListOptions{
-		Namespace: c.Project,
+// errWatchClosed is returned if the API server closed the watch.
+var errWatchClosed = errors.New("watch closed by the server")
+
+// watchNamespace watches the objects of list in namespace, starting at
+// resourceVersion, and sends all events to events until ctx is done.
+// Interrupted watches are resumed from the last seen resource version. If
+// that version is too old to resume from, the objects are listed again and
+// sent as added events. Every retry, including the ones after relisting,
+// waits according to the backoff and the watch gives up once the backoff is
+// exhausted without making any progress.
+func (c *Client) watchNamespace(
+	ctx context.Context,
+	list runtimeclient.ObjectList,
+	namespace, resourceVersion string,
+	listOptions []runtimeclient.ListOption,
+	backoff wait.Backoff,
+	events chan<- watch.Event,
+) error {
+	retry := backoff
+	for {
+		progressed, err := c.watchOnce(ctx, list, namespace, &resourceVersion, listOptions, events)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if progressed {
+			retry = backoff
+		}
+		if kerrors.IsResourceExpired(err) || kerrors.IsGone(err) {
+			// the watch is resumed from the relisted version after
+			// the backoff, so a version which keeps expiring does
+			// not relist in a tight loop.
+			rv, relistErr := c.relist(ctx, list, namespace, listOptions, events)
+			if relistErr != nil {
+				err = relistErr
+			} else {
+				resourceVersion = rv
+			}
+		}
+
+		if retry.Steps <= 0 {
+			return fmt.Errorf("watching resources in %q: %w", namespace, err)
+		}
+		select {
+		case <-time.After(retry.Step()):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// watchOnce runs a single watch until it is interrupted, updating
+// resourceVersion with every received event. It returns whether any
+// progress has been made.
+func (c *Client) watchOnce(
+	ctx context.Context,
+	list runtimeclient.ObjectList,
+	namespace string,
+	resourceVersion *string,
+	listOptions []runtimeclient.ListOption,
+	events chan<- watch.Event,
+) (bool, error) {
+	wa, err := c.Watch(ctx, newListOf(list), append(slices.Clone(listOptions), &runtimeclient.ListOptions{
+		Namespace: namespace,
 		Raw: &metav1.ListOptions{
-			// in order to get around the initial list of items, we make a
-			// previous list call and then set the resource version of the
-			// returned list.
-			ResourceVersion: list.GetResourceVersion(),
+			ResourceVersion:     *resourceVersion,
+			AllowWatchBookmarks: true,
 		},
 	})...)
 	if err != nil {
-		return fmt.Errorf("watching resources: %w", err)
+		return false, err
 	}
+	defer wa.Stop()
 
+	start := time.Now()
+	progressed := false
 	for {
 		select {
-		case res := <-wa.ResultChan():
-			if res.Type == watch.Error || res.Type == "" {
-				return fmt.Errorf("watching: %s", res.Object.GetObjectKind())
+		case res, ok := <-wa.ResultChan():
+			if !ok || res.Type == "" {
+				return progressed || time.Since(start) >= minWatchDuration, errWatchClosed
+			}
+			if res.Type == watch.Error {
+				return progressed, kerrors.FromObject(res.Object)
+			}
+			if obj, err := meta.Accessor(res.Object); err == nil {
+				*resourceVersion = obj.GetResourceVersion()
+			}
+			progressed = true
+			// bookmarks only carry the resource version to resume from.
+			if res.Type == watch.Bookmark {
+				continue
+			}
+			select {
+			case events <- res:
+			case <-ctx.Done():
+				return progressed, nil
 			}
+		case <-ctx.Done():
+			return progressed, nil
+		}
+	}
+}
+
+// relist lists the objects of namespace again and sends them as added
+// events. It returns the resource version to resume watching from.
+func (c *Client) relist(
+	ctx context.Context,
+	list runtimeclient.ObjectList,
+	namespace string,
+	listOptions []runtimeclient.ListOption,
+	events chan<- watch.Event,
+) (string, error) {
+	fresh := newListOf(list)
+	if err := c.List(ctx, fresh, append(slices.Clone(listOptions), runtimeclient.InNamespace(namespace))...); err != nil {
+		return "", err
+	}
+	items, err := meta.ExtractList(fresh)
+	if err != nil {
+		return "", err
+	}
+	for _, item := range items {
+		select {
+		case events <- watch.Event{Type: watch.Added, Object: item}:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	return fresh.GetResourceVersion(), nil
+}
+
+// watchMux multiplexes the events of watches in multiple namespaces.
+type watchMux struct {
+	client      *Client
+	list        runtimeclient.ObjectList
+	listOptions []runtimeclient.ListOption
+	backoff     wait.Backoff
+	events      chan watch.Event
+	errs        chan error
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newWatchMux(c *Client, list runtimeclient.ObjectList, listOptions []runtimeclient.ListOption, backoff wait.Backoff) *watchMux {
+	return &watchMux{
+		client:      c,
+		list:        list,
+		listOptions: listOptions,
+		backoff:     backoff,
+		events:      make(chan watch.Event),
+		errs:        make(chan error, 1),
+		cancels:     map[string]context.CancelFunc{},
+	}
+}
+
+// add starts watching namespace if it is not watched already.
+func (m *watchMux) add(ctx context.Context, namespace, resourceVersion string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.cancels[namespace]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	m.cancels[namespace] = cancel
+	go func() {
+		if err := m.client.watchNamespace(ctx, m.list, namespace, resourceVersion, m.listOptions, m.backoff, m.events); err != nil {
+			m.fail(err)
+		}
+	}()
+}
+
+// remove stops watching namespace.
+func (m *watchMux) remove(namespace string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cancel, ok := m.cancels[namespace]; ok {
+		cancel()
+		delete(m.cancels, namespace)
+	}
+}
+
+// fail stops the forwarding of events with err. Only the first error is
+// kept.
+func (m *watchMux) fail(err error) {
+	select {
+	case m.errs <- err:
+	default:
+	}
+}
+
+// forward calls watchFunc for every event with the changed object as the
+// only item of list until ctx is done or a watch failed.
+func (m *watchMux) forward(ctx context.Context, list runtimeclient.ObjectList, watchFunc WatchFunc) error {
+	for {
+		select {
+		case res := <-m.events:
 			if err := meta.SetList(list, []runtime.Object{res.Object}); err != nil {
 				return err
 			}
 			if err := watchFunc(list); err != nil {
 				return err
 			}
+		case err := <-m.errs:
+			return err
 		case <-ctx.Done():
-			wa.Stop()
 			return nil
 		}
 	}
 }
+
+// callWithItems calls watchFunc if list has any items.
+func callWithItems(list runtimeclient.ObjectList, watchFunc WatchFunc) error {
+	items, err := itemsFromObjectList(list)
+	if err != nil {
+		return err
+	}
+	if items.Len() == 0 {
+		return nil
+	}
+	return watchFunc(list)
+}
+
+// newListOf returns a new empty list of the same type as list.
+func newListOf(list runtimeclient.ObjectList) runtimeclient.ObjectList {
+	newList := reflect.New(reflect.TypeOf(list).Elem()).Interface().(runtimeclient.ObjectList)
+	newList.GetObjectKind().SetGroupVersionKind(list.GetObjectKind().GroupVersionKind())
+	return newList
+}
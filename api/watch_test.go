@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const testNamespace = "default"
+
+// fakeWatches returns a client whose watches are created by newWatcher. The
+// resource version every watch starts at is sent to versions.
+func fakeWatches(t *testing.T, versions chan<- string, newWatcher func() *watch.FakeWatcher, objects ...runtimeclient.Object) *Client {
+	scheme, err := NewScheme()
+	require.NoError(t, err)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).WithInterceptorFuncs(interceptor.Funcs{
+		Watch: func(ctx context.Context, _ runtimeclient.WithWatch, _ runtimeclient.ObjectList, opts ...runtimeclient.ListOption) (watch.Interface, error) {
+			listOpts := &runtimeclient.ListOptions{}
+			listOpts.ApplyOptions(opts)
+			select {
+			case versions <- listOpts.Raw.ResourceVersion:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return newWatcher(), nil
+		},
+	}).Build()
+	return &Client{WithWatch: c, Project: testNamespace}
+}
+
+func configMap(name, resourceVersion string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:            name,
+		Namespace:       testNamespace,
+		ResourceVersion: resourceVersion,
+	}}
+}
+
+func TestWatchNamespaceResumes(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	versions := make(chan string, 1)
+	watchers := make(chan *watch.FakeWatcher, 1)
+	c := fakeWatches(t, versions, func() *watch.FakeWatcher {
+		w := watch.NewFakeWithChanSize(2, false)
+		watchers <- w
+		return w
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	events := make(chan watch.Event)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.watchNamespace(ctx, &corev1.ConfigMapList{}, testNamespace, "1", nil,
+			wait.Backoff{Steps: 1, Duration: time.Millisecond}, events)
+	}()
+
+	is.Equal("1", <-versions)
+	w := <-watchers
+	w.Add(configMap("first", "5"))
+	is.Equal("first", (<-events).Object.(*corev1.ConfigMap).Name)
+	// bookmarks are not sent, but the watch resumes from them.
+	w.Action(watch.Bookmark, configMap("", "7"))
+	w.Stop()
+
+	is.Equal("7", <-versions)
+	w = <-watchers
+	w.Add(configMap("second", "8"))
+	is.Equal("second", (<-events).Object.(*corev1.ConfigMap).Name)
+
+	cancel()
+	is.NoError(<-done)
+}
+
+func TestWatchNamespaceExpired(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	gone := &metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   http.StatusGone,
+		Reason: metav1.StatusReasonExpired,
+	}
+	// every watch fails right away as its resource version expired.
+	versions := make(chan string, 10)
+	c := fakeWatches(t, versions, func() *watch.FakeWatcher {
+		w := watch.NewFakeWithChanSize(1, false)
+		w.Error(gone)
+		return w
+	}, configMap("existing", ""))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	events := make(chan watch.Event)
+	go func() {
+		for range events {
+		}
+	}()
+
+	err := c.watchNamespace(ctx, &corev1.ConfigMapList{}, testNamespace, "1", nil,
+		wait.Backoff{Steps: 2, Duration: time.Millisecond}, events)
+	close(events)
+	// the relists use up the backoff instead of looping until the context
+	// is done.
+	is.Error(err)
+	is.NoError(ctx.Err())
+	is.Len(versions, 3)
+	is.Equal("1", <-versions)
+}
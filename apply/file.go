@@ -2,26 +2,80 @@ package apply
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"time"
 
 	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/cli"
 	"github.com/ninech/nctl/internal/format"
-	"maps"
 
-	"k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 )
 
 type fromFile struct {
-	format.Writer `hidden:""`
-	Filename      *os.File `short:"f" completion-predictor:"file"`
+	format.Writer  `hidden:""`
+	format.Reader  `hidden:""`
+	Filename       *os.File      `short:"f" completion-predictor:"file"`
+	Recursive      bool          `short:"R" help:"Process the directory passed to -f recursively."`
+	ServerSide     bool          `help:"Use server-side apply. Applied fields are owned by the \"nctl\" field manager and fields set by others are left untouched."`
+	ForceConflicts bool          `help:"Take ownership of fields which are owned by another field manager. Only relevant with --server-side."`
+	Prune          bool          `help:"Delete resources matching --selector which are not part of the manifests anymore. Resources owned by Nine are never pruned."`
+	Selector       string        `short:"l" help:"Label selector of the resources to prune, e.g. \"team=web\". Required with --prune."`
+	Force          bool          `help:"Do not ask for confirmation before pruning resources."`
+	Wait           bool          `default:"false" help:"Wait until all applied resources are ready."`
+	WaitTimeout    time.Duration `default:"30m" help:"Duration to wait for the resources getting ready. Only relevant if wait is set."`
+	TemplateFlags  `embed:""`
+}
+
+// BeforeApply initializes Writer and Reader from Kong's bound io.Writer and io.Reader.
+// Because Kong wont apply hooks on embedded structs.
+func (cmd *fromFile) BeforeApply(writer io.Writer, reader io.Reader) error {
+	return errors.Join(
+		cmd.Writer.BeforeApply(writer),
+		cmd.Reader.BeforeApply(reader),
+	)
 }
 
 func (cmd *fromFile) Run(ctx context.Context, client *api.Client) error {
-	return File(ctx, cmd.Writer, client, cmd.Filename, UpdateOnExists())
+	opts, err := cmd.TemplateFlags.Options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, UpdateOnExists())
+	if cmd.Recursive {
+		opts = append(opts, Recursive())
+	}
+	if cmd.ServerSide {
+		opts = append(opts, ServerSide())
+	}
+	if cmd.ForceConflicts {
+		opts = append(opts, ForceConflicts())
+	}
+	if cmd.Wait {
+		opts = append(opts, Wait(cmd.WaitTimeout))
+	}
+	if cmd.Prune {
+		if cmd.Selector == "" {
+			return cli.ErrorWithContext(fmt.Errorf("--prune requires a label selector")).
+				WithExitCode(cli.ExitUsageError).
+				WithSuggestions("Select the resources to prune with -l, e.g. --prune -l team=web")
+		}
+		selector, err := labels.Parse(cmd.Selector)
+		if err != nil {
+			return cli.ErrorWithContext(fmt.Errorf("invalid label selector %q: %w", cmd.Selector, err)).
+				WithExitCode(cli.ExitUsageError)
+		}
+		opts = append(opts, Prune(selector, cmd.Reader, cmd.Force))
+	}
+	return File(ctx, cmd.Writer, client, cmd.Filename, opts...)
 }
 
 type Option func(*config)
@@ -29,6 +83,13 @@ type Option func(*config)
 type config struct {
 	updateOnExists bool
 	delete         bool
+	recursive      bool
+	serverSide     bool
+	forceConflicts bool
+	prune          *pruneConfig
+	waitTimeout    time.Duration
+	values         map[string]any
+	renderOnly     bool
 }
 
 func UpdateOnExists() Option {
@@ -43,6 +104,69 @@ func Delete() Option {
 	}
 }
 
+// Recursive makes File descend into subdirectories when it is passed a
+// directory.
+func Recursive() Option {
+	return func(c *config) {
+		c.recursive = true
+	}
+}
+
+// ServerSide makes File use server-side apply instead of creating and
+// updating objects.
+func ServerSide() Option {
+	return func(c *config) {
+		c.serverSide = true
+	}
+}
+
+// ForceConflicts makes server-side apply take ownership of fields which are
+// owned by other field managers.
+func ForceConflicts() Option {
+	return func(c *config) {
+		c.forceConflicts = true
+	}
+}
+
+// Values makes File render the manifests as Go templates with the passed
+// values.
+func Values(values map[string]any) Option {
+	return func(c *config) {
+		c.values = values
+	}
+}
+
+// RenderOnly makes File print the objects instead of processing them.
+func RenderOnly() Option {
+	return func(c *config) {
+		c.renderOnly = true
+	}
+}
+
+// Wait makes File wait until all applied objects are Ready and Synced. The
+// timeout applies to all objects together.
+func Wait(timeout time.Duration) Option {
+	return func(c *config) {
+		c.waitTimeout = timeout
+	}
+}
+
+// Prune makes File delete all nine.ch resources matching selector which are
+// not part of the applied objects. Unless force is set, the resources are
+// listed and a confirmation is read from reader before deleting them.
+func Prune(selector labels.Selector, reader format.Reader, force bool) Option {
+	return func(c *config) {
+		c.prune = &pruneConfig{selector: selector, reader: reader, force: force}
+	}
+}
+
+// File applies all objects found in file. The file can contain multiple YAML
+// documents or JSON objects and it can also be a directory, in which case all
+// YAML and JSON files within it are read. The objects are processed in an
+// order that respects dependencies between the different kinds. Processing
+// continues if a single object fails and all failures are reported at the end.
+// If waiting is enabled, File blocks until all objects are ready. Pruning
+// only happens once all objects have been applied successfully.
 func File(ctx context.Context, w format.Writer, client *api.Client, file *os.File, opts ...Option) error {
 	if file == nil {
 		return fmt.Errorf("missing flag -f, --filename=STRING")
@@ -54,11 +178,54 @@ func File(ctx context.Context, w format.Writer, client *api.Client, file *os.Fil
 		opt(cfg)
 	}
 
-	obj := &unstructured.Unstructured{}
-	if err := yaml.NewYAMLOrJSONDecoder(file, 4096).Decode(obj); err != nil {
+	objs, err := ReadObjects(file, cfg.recursive, cfg.values)
+	if err != nil {
 		return err
 	}
+	if len(objs) == 0 {
+		return fmt.Errorf("no objects found in %s", file.Name())
+	}
+	if cfg.renderOnly {
+		return printObjects(w, objs)
+	}
+	sortObjects(objs, cfg.delete)
 
+	if err := cfg.processAll(ctx, w, client, objs); err != nil {
+		return err
+	}
+	if cfg.waitTimeout > 0 && !cfg.delete {
+		if err := waitReady(ctx, w, client, objs, cfg.waitTimeout); err != nil {
+			return err
+		}
+	}
+	if cfg.prune != nil {
+		return cfg.prune.prune(ctx, w, client, objs)
+	}
+
+	return nil
+}
+
+func (cfg *config) processAll(ctx context.Context, w format.Writer, client *api.Client, objs []*unstructured.Unstructured) error {
+	// a single object keeps the plain error so callers can inspect it.
+	if len(objs) == 1 {
+		return cfg.process(ctx, w, client, objs[0])
+	}
+
+	failed := 0
+	for _, obj := range objs {
+		if err := cfg.process(ctx, w, client, obj); err != nil {
+			reportFailure(w, obj, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d resources failed", failed, len(objs))
+	}
+
+	return nil
+}
+
+func (cfg *config) process(ctx context.Context, w format.Writer, client *api.Client, obj *unstructured.Unstructured) error {
 	if cfg.delete {
 		if err := client.Delete(ctx, obj); err != nil {
 			return err
@@ -68,8 +235,16 @@ func File(ctx context.Context, w format.Writer, client *api.Client, file *os.Fil
 		return nil
 	}
 
+	if cfg.serverSide {
+		if err := serverSideApply(ctx, client, obj, cfg.forceConflicts); err != nil {
+			return err
+		}
+		w.Successf("🏗", "applied %s (server-side)", formatObj(obj))
+		return nil
+	}
+
 	if err := client.Create(ctx, obj); err != nil {
-		if errors.IsAlreadyExists(err) && cfg.updateOnExists {
+		if kerrors.IsAlreadyExists(err) && cfg.updateOnExists {
 			if err := update(ctx, client, obj); err != nil {
 				return err
 			}
@@ -83,6 +258,31 @@ func File(ctx context.Context, w format.Writer, client *api.Client, file *os.Fil
 	return nil
 }
 
+// printObjects prints objs as YAML documents.
+func printObjects(w format.Writer, objs []*unstructured.Unstructured) error {
+	for i, obj := range objs {
+		out, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			w.Printf("---\n")
+		}
+		w.Printf("%s", out)
+	}
+
+	return nil
+}
+
+// reportFailure prints the failure of processing obj.
+func reportFailure(w format.Writer, obj *unstructured.Unstructured, err error) {
+	if cliErr, ok := errors.AsType[*cli.Error](err); ok {
+		w.Println(cliErr.Error())
+		return
+	}
+	w.Failuref("💥", "%s: %s", formatObj(obj), err)
+}
+
 func update(ctx context.Context, client *api.Client, obj *unstructured.Unstructured) error {
 	oldObj := &unstructured.Unstructured{}
 	oldObj.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
@@ -90,18 +290,7 @@ func update(ctx context.Context, client *api.Client, obj *unstructured.Unstructu
 		return err
 	}
 
-	// merge annotations/labels
-	annotations, labels := oldObj.GetAnnotations(), oldObj.GetLabels()
-	maps.Copy(annotations, obj.GetAnnotations())
-	maps.Copy(labels, obj.GetLabels())
-	obj.SetAnnotations(annotations)
-	obj.SetLabels(labels)
-
-	// preserve finalizers
-	obj.SetFinalizers(append(obj.GetFinalizers(), oldObj.GetFinalizers()...))
-	// ensure resource version is up to date
-	obj.SetResourceVersion(oldObj.GetResourceVersion())
-
+	MergeExisting(obj, oldObj)
 	if err := client.Update(ctx, obj); err != nil {
 		return err
 	}
@@ -109,6 +298,25 @@ func update(ctx context.Context, client *api.Client, obj *unstructured.Unstructu
 	return nil
 }
 
+// MergeExisting merges the fields of existing which should survive an apply
+// into obj. Annotations and labels are merged, finalizers are preserved and
+// the resource version is taken over so obj can be used for an update.
+func MergeExisting(obj, existing *unstructured.Unstructured) {
+	obj.SetAnnotations(mergeMap(existing.GetAnnotations(), obj.GetAnnotations()))
+	obj.SetLabels(mergeMap(existing.GetLabels(), obj.GetLabels()))
+	obj.SetFinalizers(append(obj.GetFinalizers(), existing.GetFinalizers()...))
+	obj.SetResourceVersion(existing.GetResourceVersion())
+}
+
+// mergeMap copies all entries of src into dst and returns the result.
+func mergeMap(dst, src map[string]string) map[string]string {
+	if dst == nil {
+		return src
+	}
+	maps.Copy(dst, src)
+	return dst
+}
+
 func formatObj(obj client.Object) string {
 	return fmt.Sprintf("%s %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), obj.GetNamespace())
 }
@@ -3,6 +3,7 @@ package apply
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	runtimev1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
@@ -11,7 +12,10 @@ import (
 	"github.com/ninech/nctl/internal/test"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -181,3 +185,130 @@ func TestFile(t *testing.T) {
 		})
 	}
 }
+
+func TestFileMultipleObjects(t *testing.T) {
+	t.Parallel()
+
+	const (
+		asa = `apiVersion: iam.nine.ch/v1alpha1
+kind: APIServiceAccount
+metadata:
+  name: %s
+  namespace: default
+`
+	)
+
+	tests := map[string]struct {
+		files       map[string]string
+		existing    []client.Object
+		recursive   bool
+		wantExist   []string
+		wantMissing []string
+		wantErr     bool
+	}{
+		"multiple documents": {
+			files: map[string]string{
+				"asa.yaml": fmt.Sprintf(asa, "one") + "---\n" + fmt.Sprintf(asa, "two") + "---\n",
+			},
+			wantExist: []string{"one", "two"},
+		},
+		"failing object does not stop processing": {
+			files: map[string]string{
+				"asa.yaml": fmt.Sprintf(asa, "one") + "---\n" + fmt.Sprintf(asa, "two"),
+			},
+			existing: []client.Object{&iam.APIServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{Name: "one", Namespace: "default"},
+			}},
+			wantExist: []string{"one", "two"},
+			wantErr:   true,
+		},
+		"directory": {
+			files: map[string]string{
+				"a.yaml":     fmt.Sprintf(asa, "one"),
+				"b.json":     `{"apiVersion": "iam.nine.ch/v1alpha1", "kind": "APIServiceAccount", "metadata": {"name": "two", "namespace": "default"}}`,
+				"README.md":  "not a manifest",
+				"sub/c.yaml": fmt.Sprintf(asa, "three"),
+			},
+			wantExist:   []string{"one", "two"},
+			wantMissing: []string{"three"},
+		},
+		"directory recursive": {
+			files: map[string]string{
+				"a.yaml":     fmt.Sprintf(asa, "one"),
+				"sub/c.yaml": fmt.Sprintf(asa, "three"),
+			},
+			recursive: true,
+			wantExist: []string{"one", "three"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t, test.WithObjects(tc.existing...))
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				path := filepath.Join(dir, name)
+				is.NoError(os.MkdirAll(filepath.Dir(path), 0o755))
+				is.NoError(os.WriteFile(path, []byte(content), 0o644))
+			}
+
+			path := dir
+			if len(tc.files) == 1 {
+				for name := range tc.files {
+					path = filepath.Join(dir, name)
+				}
+			}
+			f, err := os.Open(path)
+			is.NoError(err)
+
+			opts := []Option{}
+			if tc.recursive {
+				opts = append(opts, Recursive())
+			}
+			err = File(t.Context(), format.NewWriter(t.Output()), apiClient, f, opts...)
+			if tc.wantErr {
+				is.Error(err)
+			} else {
+				is.NoError(err)
+			}
+
+			for _, name := range tc.wantExist {
+				is.NoError(apiClient.Get(t.Context(), types.NamespacedName{Name: name, Namespace: "default"}, &iam.APIServiceAccount{}))
+			}
+			for _, name := range tc.wantMissing {
+				err := apiClient.Get(t.Context(), types.NamespacedName{Name: name, Namespace: "default"}, &iam.APIServiceAccount{})
+				is.True(errors.IsNotFound(err))
+			}
+		})
+	}
+}
+
+func TestSortObjects(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	kinds := []string{"ServiceConnection", "StaticEgress", "Application", "Unknown", "PostgresDatabase", "Postgres", "Project"}
+	objs := make([]*unstructured.Unstructured, 0, len(kinds))
+	for _, kind := range kinds {
+		obj := &unstructured.Unstructured{}
+		obj.SetKind(kind)
+		objs = append(objs, obj)
+	}
+
+	sortObjects(objs, false)
+	is.Equal([]string{"Project", "Postgres", "PostgresDatabase", "Application", "Unknown", "ServiceConnection", "StaticEgress"}, objKinds(objs))
+
+	sortObjects(objs, true)
+	is.Equal([]string{"ServiceConnection", "StaticEgress", "Unknown", "Application", "PostgresDatabase", "Postgres", "Project"}, objKinds(objs))
+}
+
+func objKinds(objs []*unstructured.Unstructured) []string {
+	kinds := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		kinds = append(kinds, obj.GetKind())
+	}
+	return kinds
+}
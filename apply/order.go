@@ -0,0 +1,68 @@
+package apply
+
+import (
+	"slices"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	iam "github.com/ninech/apis/iam/v1alpha1"
+	infrastructure "github.com/ninech/apis/infrastructure/v1alpha1"
+	management "github.com/ninech/apis/management/v1alpha1"
+	networking "github.com/ninech/apis/networking/v1alpha1"
+	observability "github.com/ninech/apis/observability/v1alpha1"
+	storage "github.com/ninech/apis/storage/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// kindOrder defines the order in which kinds are applied. Kinds which are
+// referenced by others need to come first, e.g. a database instance needs to
+// exist before a ServiceConnection can point to it. Kinds which are not
+// listed are applied after all listed kinds but before the ones which
+// reference other resources (see referencingKinds).
+var kindOrder = [][]string{
+	{management.ProjectKind},
+	{"Namespace", "Secret", "ConfigMap"},
+	{iam.APIServiceAccountKind, apps.ProjectConfigKind},
+	{
+		storage.MySQLKind,
+		storage.PostgresKind,
+		storage.KeyValueStoreKind,
+		storage.OpenSearchKind,
+		storage.BucketKind,
+		infrastructure.KubernetesClusterKind,
+		infrastructure.CloudVirtualMachineKind,
+		observability.GrafanaKind,
+	},
+	{storage.MySQLDatabaseKind, storage.PostgresDatabaseKind, storage.BucketUserKind},
+	{apps.ApplicationKind},
+}
+
+// referencingKinds are applied last as they reference other resources.
+var referencingKinds = []string{
+	networking.StaticEgressKind,
+	networking.ServiceConnectionKind,
+}
+
+// kindRank returns the position of kind in the apply order.
+func kindRank(kind string) int {
+	for i, kinds := range kindOrder {
+		if slices.Contains(kinds, kind) {
+			return i
+		}
+	}
+	if slices.Contains(referencingKinds, kind) {
+		return len(kindOrder) + 1
+	}
+	return len(kindOrder)
+}
+
+// sortObjects sorts objs in dependency order. When deleting, the order is
+// reversed so that referencing resources are removed first. Objects of the
+// same kind keep the order in which they were read.
+func sortObjects(objs []*unstructured.Unstructured, reverse bool) {
+	slices.SortStableFunc(objs, func(a, b *unstructured.Unstructured) int {
+		if reverse {
+			return kindRank(b.GetKind()) - kindRank(a.GetKind())
+		}
+		return kindRank(a.GetKind()) - kindRank(b.GetKind())
+	})
+}
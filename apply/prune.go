@@ -0,0 +1,120 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/format"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type pruneConfig struct {
+	selector labels.Selector
+	reader   format.Reader
+	force    bool
+}
+
+// objectKey identifies an object across all kinds.
+type objectKey struct {
+	schema.GroupKind
+	types.NamespacedName
+}
+
+func keyOf(obj *unstructured.Unstructured) objectKey {
+	return objectKey{
+		GroupKind:      obj.GroupVersionKind().GroupKind(),
+		NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()},
+	}
+}
+
+// prune deletes all nine.ch resources matching the selector which are not
+// part of the applied objects. Resources owned by Nine are never pruned.
+func (p *pruneConfig) prune(ctx context.Context, w format.Writer, client *api.Client, applied []*unstructured.Unstructured) error {
+	candidates := p.candidates(ctx, w, client, applied)
+	if len(candidates) == 0 {
+		w.Infof("✂️", "nothing to prune")
+		return nil
+	}
+
+	w.Infof("✂️", "the following resources are not part of the manifests anymore and will be deleted:")
+	for _, obj := range candidates {
+		w.Printf("  - %s\n", formatObj(obj))
+	}
+	if !p.force {
+		ok, err := w.Confirm(p.reader, fmt.Sprintf("do you really want to delete %d resources?", len(candidates)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			w.Failuref("", "pruning canceled")
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, obj := range candidates {
+		if err := client.Delete(ctx, obj); err != nil && !kerrors.IsNotFound(err) {
+			w.Failuref("💥", "%s: %s", formatObj(obj), err)
+			failed++
+			continue
+		}
+		w.Successf("🗑", "pruned %s", formatObj(obj))
+	}
+	if failed > 0 {
+		return fmt.Errorf("unable to prune %d of %d resources", failed, len(candidates))
+	}
+
+	return nil
+}
+
+// candidates lists all resources which should be pruned. The project of the
+// client and all namespaces of the applied objects are searched.
+func (p *pruneConfig) candidates(ctx context.Context, w format.Writer, client *api.Client, applied []*unstructured.Unstructured) []*unstructured.Unstructured {
+	keep := make(map[objectKey]struct{}, len(applied))
+	namespaces := []string{client.Project}
+	for _, obj := range applied {
+		keep[keyOf(obj)] = struct{}{}
+		if ns := obj.GetNamespace(); ns != "" && !slices.Contains(namespaces, ns) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	var result []*unstructured.Unstructured
+	for _, namespace := range namespaces {
+		for _, listType := range api.NineListTypes(client.Scheme()) {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(listType)
+			if err := client.List(
+				ctx, list,
+				runtimeclient.InNamespace(namespace),
+				runtimeclient.MatchingLabelsSelector{Selector: p.selector},
+			); err != nil {
+				// a missing resource could only lead to a resource
+				// not being pruned, so we continue with a warning.
+				if !kerrors.IsForbidden(err) {
+					w.Warningf("unable to list %s in %s: %s", listType.Kind, namespace, err)
+				}
+				continue
+			}
+			for _, item := range list.Items {
+				if api.IsNineOwned(&item) {
+					continue
+				}
+				if _, ok := keep[keyOf(&item)]; ok {
+					continue
+				}
+				result = append(result, &item)
+			}
+		}
+	}
+	sortObjects(result, true)
+
+	return result
+}
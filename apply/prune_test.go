@@ -0,0 +1,94 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iam "github.com/ninech/apis/iam/v1alpha1"
+	meta "github.com/ninech/apis/meta/v1alpha1"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	const manifest = `apiVersion: iam.nine.ch/v1alpha1
+kind: APIServiceAccount
+metadata:
+  name: keep
+  namespace: default
+  labels:
+    team: web
+`
+
+	asa := func(name string, lbls map[string]string) client.Object {
+		return &iam.APIServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: test.DefaultProject, Labels: lbls},
+		}
+	}
+	existing := []client.Object{
+		asa("keep", map[string]string{"team": "web"}),
+		asa("old", map[string]string{"team": "web"}),
+		asa("other-team", map[string]string{"team": "api"}),
+		asa("nine-owned", map[string]string{"team": "web", meta.NineOwnedLabelKey: meta.NineOwnedLabelValue}),
+	}
+
+	tests := map[string]struct {
+		input      string
+		force      bool
+		wantPruned []string
+		wantExist  []string
+	}{
+		"confirmed": {
+			input:      "y\n",
+			wantPruned: []string{"old"},
+			wantExist:  []string{"keep", "other-team", "nine-owned"},
+		},
+		"canceled": {
+			input:     "n\n",
+			wantExist: []string{"keep", "old", "other-team", "nine-owned"},
+		},
+		"forced": {
+			force:      true,
+			wantPruned: []string{"old"},
+			wantExist:  []string{"keep", "other-team", "nine-owned"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t, test.WithObjects(existing...))
+			path := filepath.Join(t.TempDir(), "asa.yaml")
+			is.NoError(os.WriteFile(path, []byte(manifest), 0o644))
+			f, err := os.Open(path)
+			is.NoError(err)
+
+			selector, err := labels.Parse("team=web")
+			is.NoError(err)
+			is.NoError(File(
+				t.Context(), format.NewWriter(t.Output()), apiClient, f,
+				UpdateOnExists(), Prune(selector, format.NewReader(strings.NewReader(tc.input)), tc.force),
+			))
+
+			for _, name := range tc.wantExist {
+				is.NoError(apiClient.Get(t.Context(), types.NamespacedName{Name: name, Namespace: test.DefaultProject}, &iam.APIServiceAccount{}))
+			}
+			for _, name := range tc.wantPruned {
+				err := apiClient.Get(t.Context(), types.NamespacedName{Name: name, Namespace: test.DefaultProject}, &iam.APIServiceAccount{})
+				is.True(kerrors.IsNotFound(err))
+			}
+		})
+	}
+}
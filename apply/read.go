@@ -0,0 +1,115 @@
+package apply
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// manifestExtensions are the file extensions which are read when a directory
+// is passed.
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+// ReadObjects reads all objects from file. If file is a directory, all
+// manifests within it are read in lexical order. Subdirectories are only
+// read if recursive is set. If values is not nil, every manifest is rendered
+// as a Go template with the values before decoding it.
+func ReadObjects(file *os.File, recursive bool, values map[string]any) ([]*unstructured.Unstructured, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readManifest(file, file.Name(), values)
+	}
+
+	root := file.Name()
+	var objs []*unstructured.Unstructured
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !slices.Contains(manifestExtensions, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		fileObjs, err := readManifest(f, path, values)
+		if err != nil {
+			return err
+		}
+		objs = append(objs, fileObjs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objs, nil
+}
+
+// readManifest decodes the objects of a single manifest and renders it
+// first if values are set.
+func readManifest(r io.Reader, name string, values map[string]any) ([]*unstructured.Unstructured, error) {
+	if values == nil {
+		return decodeObjects(r, name)
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := render(name, content, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeObjects(bytes.NewReader(rendered), name)
+}
+
+// decodeObjects decodes all YAML documents or JSON objects from r. Empty
+// documents are skipped.
+func decodeObjects(r io.Reader, name string) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		raw := runtime.RawExtension{}
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, fmt.Errorf("unable to decode %s: %w", name, err)
+		}
+		raw.Raw = bytes.TrimSpace(raw.Raw)
+		if len(raw.Raw) == 0 || bytes.Equal(raw.Raw, []byte("null")) {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(raw.Raw); err != nil {
+			return nil, fmt.Errorf("unable to decode %s: %w", name, err)
+		}
+		objs = append(objs, obj)
+	}
+}
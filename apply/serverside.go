@@ -0,0 +1,70 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/cli"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FieldManager is the name of the field manager used for server-side apply.
+const FieldManager = cli.Name
+
+// serverSideApply applies obj using server-side apply. The fields set in obj
+// are owned by [FieldManager] afterwards. If force is set, fields owned by
+// other field managers are taken over instead of failing with a conflict.
+func serverSideApply(ctx context.Context, client *api.Client, obj *unstructured.Unstructured, force bool) error {
+	// the API server rejects apply requests which contain managed fields
+	// and the resource version would turn the apply into a conditional
+	// request.
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+
+	opts := []runtimeclient.ApplyOption{runtimeclient.FieldOwner(FieldManager)}
+	if force {
+		opts = append(opts, runtimeclient.ForceOwnership)
+	}
+	if err := client.Apply(ctx, runtimeclient.ApplyConfigurationFromUnstructured(obj), opts...); err != nil {
+		return conflictError(err, obj)
+	}
+
+	return nil
+}
+
+// conflictError converts a server-side apply conflict into a [cli.Error]
+// which lists the conflicting field managers and paths. Other errors are
+// returned unchanged.
+func conflictError(err error, obj *unstructured.Unstructured) error {
+	statusErr, ok := errors.AsType[*kerrors.StatusError](err)
+	if !ok || !kerrors.IsConflict(err) || statusErr.Status().Details == nil {
+		return err
+	}
+
+	var conflicts []string
+	for _, cause := range statusErr.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%s (%s)", cause.Field, cause.Message))
+	}
+	if len(conflicts) == 0 {
+		return err
+	}
+
+	return cli.ErrorWithContext(fmt.Errorf(
+		"%s has %d field conflicts:\n  - %s",
+		formatObj(obj), len(conflicts), strings.Join(conflicts, "\n  - "),
+	)).
+		WithContext("Field manager", FieldManager).
+		WithSuggestions(
+			"Take ownership of the conflicting fields with --force-conflicts",
+			"Remove the conflicting fields from the manifest to leave them to the other field manager",
+		)
+}
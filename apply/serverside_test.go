@@ -0,0 +1,91 @@
+package apply
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	runtimev1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	iam "github.com/ninech/apis/iam/v1alpha1"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestServerSideApply(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	apiClient := test.SetupClient(t)
+	path := filepath.Join(t.TempDir(), "asa.yaml")
+
+	for _, policy := range []runtimev1.DeletionPolicy{runtimev1.DeletionDelete, runtimev1.DeletionOrphan} {
+		is.NoError(os.WriteFile(path, fmt.Appendf(nil, apiServiceAccountYAML, "ssa", "value", policy), 0o644))
+		f, err := os.Open(path)
+		is.NoError(err)
+
+		is.NoError(File(t.Context(), format.NewWriter(t.Output()), apiClient, f, ServerSide()))
+
+		asa := &iam.APIServiceAccount{}
+		is.NoError(apiClient.Get(t.Context(), types.NamespacedName{Name: "ssa", Namespace: "default"}, asa))
+		is.Equal(policy, asa.Spec.DeletionPolicy)
+		is.Equal("value", asa.Annotations["key"])
+	}
+}
+
+func TestConflictError(t *testing.T) {
+	t.Parallel()
+
+	obj := &unstructured.Unstructured{}
+	obj.SetKind(iam.APIServiceAccountKind)
+	obj.SetName("ssa")
+	obj.SetNamespace("default")
+
+	conflict := kerrors.NewApplyConflict([]metav1.StatusCause{{
+		Type:    metav1.CauseTypeFieldManagerConflict,
+		Message: `conflict with "cockpit"`,
+		Field:   ".spec.deletionPolicy",
+	}}, "Apply failed with 1 conflict")
+
+	tests := map[string]struct {
+		err        error
+		wantCLIErr bool
+	}{
+		"field manager conflict": {
+			err:        conflict,
+			wantCLIErr: true,
+		},
+		"other conflict": {
+			err: kerrors.NewConflict(schema.GroupResource{}, "ssa", fmt.Errorf("object has been modified")),
+		},
+		"not found": {
+			err: kerrors.NewNotFound(schema.GroupResource{}, "ssa"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			err := conflictError(tc.err, obj)
+			if !tc.wantCLIErr {
+				is.Equal(tc.err, err)
+				return
+			}
+
+			cliErr, ok := err.(*cli.Error)
+			is.True(ok)
+			is.Contains(cliErr.Error(), ".spec.deletionPolicy")
+			is.Contains(cliErr.Error(), "cockpit")
+			is.Contains(cliErr.Error(), "--force-conflicts")
+		})
+	}
+}
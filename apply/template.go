@@ -0,0 +1,159 @@
+package apply
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadValues reads the values which are passed to templated manifests. The
+// values files are merged in the given order, later files taking precedence.
+// Each entry of set has the form "key=value" where key can address nested
+// values with dots, e.g. "database.size=large". The entries of setString
+// have the same form, but their values are always strings. Values from set
+// and setString take precedence over the ones from files.
+func LoadValues(files, set, setString []string) (map[string]any, error) {
+	values := map[string]any{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read values file: %w", err)
+		}
+		fileValues := map[string]any{}
+		if err := yaml.Unmarshal(content, &fileValues); err != nil {
+			return nil, fmt.Errorf("unable to parse values file %s: %w", file, err)
+		}
+		mergeValues(values, fileValues)
+	}
+
+	for _, s := range set {
+		if err := setValue(values, s, parseValue); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range setString {
+		if err := setValue(values, s, func(v string) any { return v }); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// mergeValues deeply merges src into dst.
+func mergeValues(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := dst[k].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// setValue sets the value of an entry of the form "key=value", converting
+// the value with parse.
+func setValue(values map[string]any, s string, parse func(string) any) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid value %q, expected key=value", s)
+	}
+	if err := setPath(values, strings.Split(key, "."), parse(value)); err != nil {
+		return fmt.Errorf("unable to set %q: %w", key, err)
+	}
+	return nil
+}
+
+func setPath(values map[string]any, path []string, value any) error {
+	for i, key := range path[:len(path)-1] {
+		next, ok := values[key]
+		if !ok {
+			next = map[string]any{}
+			values[key] = next
+		}
+		nextMap, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s is not a map", strings.Join(path[:i+1], "."))
+		}
+		values = nextMap
+	}
+	values[path[len(path)-1]] = value
+
+	return nil
+}
+
+// parseValue converts booleans and integers passed with --set to their types
+// so they can be used in template conditions and comparisons. Values which
+// would change when formatted again, like "1.20" or "007", are kept as
+// strings. --set-string can be used to pass any value as string.
+func parseValue(s string) any {
+	if s == "true" || s == "false" {
+		return s == "true"
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s {
+		return i
+	}
+	return s
+}
+
+// render executes content as a Go template. The values are available as
+// .Values and all sprig functions can be used. Referencing a value which
+// does not exist is an error.
+func render(name string, content []byte, values map[string]any) ([]byte, error) {
+	tmpl, err := template.New(name).
+		Funcs(sprig.TxtFuncMap()).
+		Option("missingkey=error").
+		Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse template %s: %w", name, err)
+	}
+
+	out := &bytes.Buffer{}
+	if err := tmpl.Execute(out, map[string]any{"Values": values}); err != nil {
+		return nil, fmt.Errorf("unable to render template %s: %w", name, err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// TemplateFlags are the flags to render manifests as Go templates. They can
+// be embedded in commands reading manifests.
+type TemplateFlags struct {
+	Values     []string `type:"existingfile" help:"Render the manifests as Go templates with the values of these YAML files. Values are accessible as {{ .Values.key }}. Later files take precedence."`
+	Set        []string `help:"Set a template value, e.g. --set database.size=large. Booleans and integers are typed. Takes precedence over values files."`
+	SetString  []string `help:"Set a template value as string, e.g. --set-string version=1.20. Takes precedence over values files."`
+	RenderOnly bool     `help:"Only print the rendered manifests without sending them to the API."`
+}
+
+// LoadValues returns the values to render manifests with according to the
+// flags. It returns nil if none of the flags is set, in which case manifests
+// are not rendered.
+func (f TemplateFlags) LoadValues() (map[string]any, error) {
+	if len(f.Values) == 0 && len(f.Set) == 0 && len(f.SetString) == 0 && !f.RenderOnly {
+		return nil, nil
+	}
+	return LoadValues(f.Values, f.Set, f.SetString)
+}
+
+// Options returns the options to render manifests according to the flags.
+// Manifests are only rendered if any of the flags is set.
+func (f TemplateFlags) Options() ([]Option, error) {
+	values, err := f.LoadValues()
+	if err != nil || values == nil {
+		return nil, err
+	}
+	opts := []Option{Values(values)}
+	if f.RenderOnly {
+		opts = append(opts, RenderOnly())
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,167 @@
+package apply
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	iam "github.com/ninech/apis/iam/v1alpha1"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestLoadValues(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	prod := filepath.Join(dir, "prod.yaml")
+	require.NoError(t, os.WriteFile(base, []byte("size: small\ndatabase:\n  name: web\n  version: 16\n"), 0o644))
+	require.NoError(t, os.WriteFile(prod, []byte("size: large\ndatabase:\n  version: 17\n"), 0o644))
+
+	tests := map[string]struct {
+		files     []string
+		set       []string
+		setString []string
+		want      map[string]any
+		wantErr   bool
+	}{
+		"files are merged": {
+			files: []string{base, prod},
+			want: map[string]any{
+				"size":     "large",
+				"database": map[string]any{"name": "web", "version": float64(17)},
+			},
+		},
+		"set overrides files": {
+			files: []string{base},
+			set:   []string{"database.name=api", "replicas=3", "debug=true", "new.nested.key=value"},
+			want: map[string]any{
+				"size":     "small",
+				"database": map[string]any{"name": "api", "version": float64(16)},
+				"replicas": int64(3),
+				"debug":    true,
+				"new":      map[string]any{"nested": map[string]any{"key": "value"}},
+			},
+		},
+		"numbers which change when formatted stay strings": {
+			set: []string{"version=1.20", "zip=007", "ratio=0.5", "zero=0", "negative=-1"},
+			want: map[string]any{
+				"version":  "1.20",
+				"zip":      "007",
+				"ratio":    "0.5",
+				"zero":     int64(0),
+				"negative": int64(-1),
+			},
+		},
+		"set string": {
+			files:     []string{base},
+			set:       []string{"replicas=3"},
+			setString: []string{"replicas=4", "debug=true", "database.version=17"},
+			want: map[string]any{
+				"size":     "small",
+				"database": map[string]any{"name": "web", "version": "17"},
+				"replicas": "4",
+				"debug":    "true",
+			},
+		},
+		"invalid set string": {
+			setString: []string{"=value"},
+			wantErr:   true,
+		},
+		"invalid set": {
+			set:     []string{"novalue"},
+			wantErr: true,
+		},
+		"set below a scalar": {
+			files:   []string{base},
+			set:     []string{"size.small=true"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			values, err := LoadValues(tc.files, tc.set, tc.setString)
+			if tc.wantErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+			is.Equal(tc.want, values)
+		})
+	}
+}
+
+func TestFileTemplate(t *testing.T) {
+	t.Parallel()
+
+	const manifest = `apiVersion: iam.nine.ch/v1alpha1
+kind: APIServiceAccount
+metadata:
+  name: {{ .Values.name }}
+  namespace: default
+spec:
+  deletionPolicy: {{ .Values.policy | default "Delete" }}
+`
+
+	tests := map[string]struct {
+		set        []string
+		renderOnly bool
+		wantOutput string
+		wantErr    bool
+	}{
+		"applied": {
+			set: []string{"name=templated", "policy=Orphan"},
+		},
+		"render only": {
+			set:        []string{"name=templated", "policy=Orphan"},
+			renderOnly: true,
+			wantOutput: "deletionPolicy: Orphan",
+		},
+		"missing value": {
+			set:     []string{"policy=Orphan"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t)
+			path := filepath.Join(t.TempDir(), "asa.yaml")
+			is.NoError(os.WriteFile(path, []byte(manifest), 0o644))
+			f, err := os.Open(path)
+			is.NoError(err)
+
+			opts, err := TemplateFlags{Set: tc.set, RenderOnly: tc.renderOnly}.Options()
+			is.NoError(err)
+
+			out := &bytes.Buffer{}
+			err = File(t.Context(), format.NewWriter(out), apiClient, f, opts...)
+			if tc.wantErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+
+			asa := &iam.APIServiceAccount{}
+			err = apiClient.Get(t.Context(), types.NamespacedName{Name: "templated", Namespace: "default"}, asa)
+			if tc.renderOnly {
+				is.Error(err)
+				is.Contains(out.String(), tc.wantOutput)
+				return
+			}
+			is.NoError(err)
+			is.EqualValues("Orphan", asa.Spec.DeletionPolicy)
+		})
+	}
+}
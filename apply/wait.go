@@ -0,0 +1,177 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	runtimev1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// watchRetryInterval is the time to wait before a failed watch is restarted.
+const watchRetryInterval = time.Second
+
+// conditioned is implemented by all resources reporting crossplane
+// conditions.
+type conditioned interface {
+	GetCondition(runtimev1.ConditionType) runtimev1.Condition
+}
+
+// waitReady waits until all objects are Ready and Synced. Objects of kinds
+// that don't report any conditions are skipped. The timeout applies to all
+// objects together.
+func waitReady(ctx context.Context, w format.Writer, client *api.Client, objs []*unstructured.Unstructured, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, obj := range objs {
+		if !reportsConditions(client.Scheme(), obj) {
+			continue
+		}
+		if err := waitObjectReady(ctx, w, client, obj); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportsConditions returns true if the kind of obj is known and reports
+// crossplane conditions.
+func reportsConditions(scheme *runtime.Scheme, obj *unstructured.Unstructured) bool {
+	typed, err := scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return false
+	}
+	_, ok := typed.(conditioned)
+	return ok
+}
+
+func waitObjectReady(ctx context.Context, w format.Writer, client *api.Client, obj *unstructured.Unstructured) error {
+	waitMsg := fmt.Sprintf("waiting for %s to be ready", formatObj(obj))
+	spinner, err := w.Spinner(format.Progress("⏳", waitMsg), format.Progress("⏳", waitMsg))
+	if err != nil {
+		return err
+	}
+	_ = spinner.Start()
+
+	start := time.Now()
+	reason := ""
+	for {
+		ready, msg, resourceVersion, err := objectReady(ctx, client, obj)
+		if err == nil && ready {
+			_ = spinner.Stop()
+			w.Successf("🛫", "%s ready (%s)", formatObj(obj), time.Since(start).Truncate(time.Second))
+			return nil
+		}
+		if msg != "" {
+			reason = msg
+			spinner.Message(format.Progressf("⏳", "%s: %s", waitMsg, reason))
+		}
+		// the object might not be visible yet right after creating it.
+		if err == nil || kerrors.IsNotFound(err) {
+			err = waitForChange(ctx, client, obj, resourceVersion)
+		}
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			_ = spinner.StopFail()
+			return err
+		}
+		spinner.StopFailMessage(format.Progressf("", "timeout waiting for %s", formatObj(obj)))
+		_ = spinner.StopFail()
+
+		cliErr := cli.ErrorWithContext(fmt.Errorf("timeout waiting for %s to be ready", formatObj(obj))).
+			WithExitCode(cli.ExitError)
+		if reason != "" {
+			cliErr = cliErr.WithContext("Reason", reason)
+		}
+		return cliErr
+	}
+}
+
+// objectReady fetches the current state of obj and returns whether it is
+// Ready and Synced. If it is not, the message of the first condition which
+// is not true is returned. The resource version of the fetched object is
+// returned to be able to watch for changes after it.
+func objectReady(ctx context.Context, client *api.Client, obj *unstructured.Unstructured) (bool, string, string, error) {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := client.Get(ctx, runtimeclient.ObjectKeyFromObject(obj), current); err != nil {
+		return false, "", "", err
+	}
+
+	status := runtimev1.ConditionedStatus{}
+	if raw, ok := current.Object["status"].(map[string]any); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &status); err != nil {
+			return false, "", "", err
+		}
+	}
+
+	for _, ct := range []runtimev1.ConditionType{runtimev1.TypeSynced, runtimev1.TypeReady} {
+		cond := status.GetCondition(ct)
+		if cond.Status != corev1.ConditionTrue {
+			return false, cond.Message, current.GetResourceVersion(), nil
+		}
+	}
+
+	return true, "", current.GetResourceVersion(), nil
+}
+
+// waitForChange blocks until obj has changed after resourceVersion, the
+// watch fails or the context is done. Failed or closed watches are retried
+// after [watchRetryInterval].
+func waitForChange(ctx context.Context, client *api.Client, obj *unstructured.Unstructured, resourceVersion string) error {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(obj.GroupVersionKind().GroupVersion().WithKind(obj.GetKind() + "List"))
+	wa, err := client.Watch(ctx, list, &runtimeclient.ListOptions{
+		Namespace:     obj.GetNamespace(),
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", obj.GetName()),
+		Raw:           &metav1.ListOptions{ResourceVersion: resourceVersion},
+	})
+	if err != nil {
+		return waitRetry(ctx)
+	}
+	defer wa.Stop()
+
+	for {
+		select {
+		case res, ok := <-wa.ResultChan():
+			// a watch which ends right away would otherwise be
+			// restarted in a tight loop.
+			if !ok || res.Type == watch.Error {
+				return waitRetry(ctx)
+			}
+			changed, ok := res.Object.(runtimeclient.Object)
+			if ok && changed.GetName() == obj.GetName() {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitRetry waits for [watchRetryInterval] or until the context is done.
+func waitRetry(ctx context.Context) error {
+	select {
+	case <-time.After(watchRetryInterval):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,133 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	runtimev1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	iam "github.com/ninech/apis/iam/v1alpha1"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var errTest = errors.New("something went wrong")
+
+func TestWaitReady(t *testing.T) {
+	t.Parallel()
+
+	asa := func(conditions ...runtimev1.Condition) *iam.APIServiceAccount {
+		a := &iam.APIServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: iam.SchemeGroupVersion.String(), Kind: iam.APIServiceAccountKind},
+			ObjectMeta: metav1.ObjectMeta{Name: "asa", Namespace: test.DefaultProject},
+		}
+		a.SetConditions(conditions...)
+		return a
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: test.DefaultProject},
+	}
+
+	tests := map[string]struct {
+		existing    client.Object
+		becomeReady bool
+		wantReason  string
+		wantErr     bool
+	}{
+		"ready": {
+			existing: asa(runtimev1.Available(), runtimev1.ReconcileSuccess()),
+		},
+		"becomes ready": {
+			existing:    asa(),
+			becomeReady: true,
+		},
+		"not synced": {
+			existing:   asa(runtimev1.Available(), runtimev1.ReconcileError(errTest)),
+			wantReason: errTest.Error(),
+			wantErr:    true,
+		},
+		"kind without conditions": {
+			existing: secret,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t, test.WithObjects(tc.existing))
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(tc.existing.GetObjectKind().GroupVersionKind())
+			obj.SetName(tc.existing.GetName())
+			obj.SetNamespace(tc.existing.GetNamespace())
+
+			if tc.becomeReady {
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					current := &iam.APIServiceAccount{}
+					if err := apiClient.Get(t.Context(), client.ObjectKeyFromObject(tc.existing), current); err != nil {
+						return
+					}
+					current.SetConditions(runtimev1.Available(), runtimev1.ReconcileSuccess())
+					_ = apiClient.Update(t.Context(), current)
+				}()
+			}
+
+			err := waitReady(t.Context(), format.NewWriter(t.Output()), apiClient, []*unstructured.Unstructured{obj}, 2*time.Second)
+			if !tc.wantErr {
+				is.NoError(err)
+				return
+			}
+
+			is.Error(err)
+			cliErr, ok := err.(*cli.Error)
+			is.True(ok)
+			is.Equal(cli.ExitError, cliErr.ExitCode())
+			is.Equal(tc.wantReason, cliErr.Context["Reason"])
+		})
+	}
+}
+
+func TestWaitReadyClosedWatch(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	existing := &iam.APIServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: iam.SchemeGroupVersion.String(), Kind: iam.APIServiceAccountKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "asa", Namespace: test.DefaultProject},
+	}
+	// every watch is closed right away, which must not restart the watch
+	// in a tight loop.
+	watches := atomic.Int32{}
+	apiClient := test.SetupClient(t,
+		test.WithObjects(existing),
+		test.WithInterceptorFuncs(interceptor.Funcs{
+			Watch: func(_ context.Context, _ client.WithWatch, _ client.ObjectList, _ ...client.ListOption) (watch.Interface, error) {
+				watches.Add(1)
+				w := watch.NewFake()
+				w.Stop()
+				return w, nil
+			},
+		}),
+	)
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(existing.GetObjectKind().GroupVersionKind())
+	obj.SetName(existing.GetName())
+	obj.SetNamespace(existing.GetNamespace())
+
+	err := waitReady(t.Context(), format.NewWriter(t.Output()), apiClient, []*unstructured.Unstructured{obj}, 1500*time.Millisecond)
+	is.Error(err)
+	is.LessOrEqual(watches.Load(), int32(2))
+}
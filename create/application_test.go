@@ -831,6 +831,101 @@ func TestApplicationBuildFail(t *testing.T) {
 	is.Equal(test.CountLines(buf.String()), errorLogLines)
 }
 
+// TestApplicationBuildLogsFromServer runs the build of a new application
+// against the fake Loki server. The logbox tails the builds of the
+// application while waiting and the log of the failed build is printed.
+func TestApplicationBuildLogsFromServer(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	cmd := applicationCmd{
+		resourceCmd: resourceCmd{
+			Wait:        true,
+			WaitTimeout: time.Second * 5,
+			Name:        "some-name",
+		},
+		SkipRepoAccessCheck: true,
+	}
+	project := test.DefaultProject
+
+	build := &apps.Build{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "any-name",
+			Namespace: project,
+			Labels: map[string]string{
+				application.ApplicationNameLabel: cmd.Name,
+			},
+		},
+	}
+	client := test.SetupClient(t, test.WithObjects(build))
+
+	buildStream := func(name string, lines ...string) log.Stream {
+		stream := log.Stream{Labels: map[string]string{
+			"namespace":              project,
+			apps.LogLabelApplication: cmd.Name,
+			apps.LogLabelBuild:       name,
+		}}
+		for _, line := range lines {
+			stream.Entries = append(stream.Entries, log.Entry{Timestamp: time.Now(), Line: line})
+		}
+		return stream
+	}
+	server, logClient := log.NewTestServer(t,
+		buildStream(build.Name, "installing dependencies"),
+		buildStream("other-build", "log of another build"),
+	)
+
+	var buf bytes.Buffer
+	out, err := output.NewLogOutput(&buf, log.Mode("default"), &output.LogOutputOptions{
+		NoLabels: true, ColoredOutput: false, Timezone: time.Local,
+	})
+	is.NoError(err)
+	logClient.StdOut = out
+	client.Log = logClient
+
+	ctx := t.Context()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	done := make(chan struct{})
+	errors := make(chan error, 1)
+	go func() {
+		defer close(errors)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// the build logs while the logbox tails it.
+				server.Push(buildStream(build.Name, "compiling assets failed"))
+
+				app := &apps.Application{ObjectMeta: metav1.ObjectMeta{
+					Name:      cmd.Name,
+					Namespace: project,
+				}}
+				if err := setResourceCondition(ctx, client, app, runtimev1.ReconcileSuccess()); err != nil {
+					errors <- err
+					return
+				}
+				build.Status.AtProvider.BuildStatus = buildStatusError
+				if err := client.Update(ctx, build); err != nil {
+					errors <- err
+					return
+				}
+			}
+		}
+	}()
+
+	is.Error(cmd.Run(ctx, client))
+	close(done)
+	for err := range errors {
+		is.NoError(err)
+	}
+
+	is.Contains(buf.String(), "installing dependencies")
+	is.Contains(buf.String(), "compiling assets failed")
+	is.NotContains(buf.String(), "log of another build")
+}
+
 func setResourceCondition(ctx context.Context, apiClient *api.Client, mg resource.Managed, condition runtimev1.Condition) error {
 	if err := apiClient.Get(ctx, api.ObjectName(mg), mg); err != nil {
 		return err
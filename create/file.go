@@ -3,6 +3,7 @@ package create
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/ninech/nctl/api"
 	"github.com/ninech/nctl/apply"
@@ -11,9 +12,23 @@ import (
 
 type fromFile struct {
 	format.Writer
-	Filename *os.File `short:"f" help:"Create any resource from a yaml or json file." completion-predictor:"file"`
+	Filename            *os.File      `short:"f" help:"Create any resource from a yaml or json file." completion-predictor:"file"`
+	Recursive           bool          `short:"R" help:"Process the directory passed to -f recursively."`
+	Wait                bool          `default:"false" help:"Wait until all created resources are ready."`
+	WaitTimeout         time.Duration `default:"30m" help:"Duration to wait for the resources getting ready. Only relevant if wait is set."`
+	apply.TemplateFlags `embed:""`
 }
 
 func (cmd *fromFile) Run(ctx context.Context, client *api.Client) error {
-	return apply.File(ctx, cmd.Writer, client, cmd.Filename)
+	opts, err := cmd.TemplateFlags.Options()
+	if err != nil {
+		return err
+	}
+	if cmd.Recursive {
+		opts = append(opts, apply.Recursive())
+	}
+	if cmd.Wait {
+		opts = append(opts, apply.Wait(cmd.WaitTimeout))
+	}
+	return apply.File(ctx, cmd.Writer, client, cmd.Filename, opts...)
 }
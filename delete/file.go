@@ -11,9 +11,14 @@ import (
 
 type fromFile struct {
 	format.Writer
-	Filename *os.File `short:"f" completion-predictor:"file"`
+	Filename  *os.File `short:"f" completion-predictor:"file"`
+	Recursive bool     `short:"R" help:"Process the directory passed to -f recursively."`
 }
 
 func (cmd *fromFile) Run(ctx context.Context, client *api.Client) error {
-	return apply.File(ctx, cmd.Writer, client, cmd.Filename, apply.Delete())
+	opts := []apply.Option{apply.Delete()}
+	if cmd.Recursive {
+		opts = append(opts, apply.Recursive())
+	}
+	return apply.File(ctx, cmd.Writer, client, cmd.Filename, opts...)
 }
@@ -0,0 +1,447 @@
+// Package describe provides a human oriented summary of a single resource.
+package describe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/kong"
+	runtimev1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	iam "github.com/ninech/apis/iam/v1alpha1"
+	infrastructure "github.com/ninech/apis/infrastructure/v1alpha1"
+	management "github.com/ninech/apis/management/v1alpha1"
+	networking "github.com/ninech/apis/networking/v1alpha1"
+	observability "github.com/ninech/apis/observability/v1alpha1"
+	storage "github.com/ninech/apis/storage/v1alpha1"
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/application"
+	"github.com/ninech/nctl/internal/format"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type Cmd struct {
+	VCluster            resourceCmd `cmd:"" group:"describe-infra" name:"vcluster" aliases:"cluster,kubernetescluster" help:"Describe a vcluster."`
+	APIServiceAccount   resourceCmd `cmd:"" group:"describe-access" name:"apiserviceaccount" aliases:"asa" help:"Describe an API Service Account."`
+	Project             resourceCmd `cmd:"" group:"describe-access" name:"project" help:"Describe a project."`
+	ProjectConfig       resourceCmd `cmd:"" group:"describe-apps" name:"project-config" aliases:"config,projectconfig" help:"Describe a deplo.io Project Configuration."`
+	Application         resourceCmd `cmd:"" group:"describe-apps" name:"application" aliases:"app" help:"Describe a deplo.io Application."`
+	Build               resourceCmd `cmd:"" group:"describe-apps" name:"build" help:"Describe a deplo.io Build."`
+	Release             resourceCmd `cmd:"" group:"describe-apps" name:"release" help:"Describe a deplo.io Release."`
+	MySQL               resourceCmd `cmd:"" group:"describe-storage" name:"mysql" help:"Describe a MySQL instance."`
+	MySQLDatabase       resourceCmd `cmd:"" group:"describe-storage" name:"mysqldatabase" help:"Describe a MySQL database."`
+	Postgres            resourceCmd `cmd:"" group:"describe-storage" name:"postgres" help:"Describe a PostgreSQL instance."`
+	PostgresDatabase    resourceCmd `cmd:"" group:"describe-storage" name:"postgresdatabase" help:"Describe a PostgreSQL database."`
+	KeyValueStore       resourceCmd `cmd:"" group:"describe-storage" name:"keyvaluestore" aliases:"kvs" help:"Describe a KeyValueStore instance."`
+	OpenSearch          resourceCmd `cmd:"" group:"describe-storage" name:"opensearch" aliases:"os" help:"Describe an OpenSearch cluster."`
+	Bucket              resourceCmd `cmd:"" group:"describe-storage" name:"bucket" help:"Describe a Bucket."`
+	BucketUser          resourceCmd `cmd:"" group:"describe-storage" name:"bucketuser" aliases:"bu" help:"Describe a BucketUser."`
+	CloudVirtualMachine resourceCmd `cmd:"" group:"describe-infra" name:"cloudvirtualmachine" aliases:"cloudvm" help:"Describe a CloudVM."`
+	ServiceConnection   resourceCmd `cmd:"" group:"describe-network" name:"serviceconnection" aliases:"sc" help:"Describe a ServiceConnection."`
+	StaticEgress        resourceCmd `cmd:"" group:"describe-network" name:"staticegress" aliases:"se" help:"Describe a StaticEgress."`
+	Grafana             resourceCmd `cmd:"" group:"describe-observability" name:"grafana" help:"Describe a Grafana instance."`
+}
+
+// kinds maps the name of every subcommand to the kind it describes.
+var kinds = map[string]schema.GroupVersionKind{
+	"vcluster":            infrastructure.SchemeGroupVersion.WithKind(infrastructure.KubernetesClusterKind),
+	"apiserviceaccount":   iam.SchemeGroupVersion.WithKind(iam.APIServiceAccountKind),
+	"project":             management.SchemeGroupVersion.WithKind(management.ProjectKind),
+	"project-config":      apps.SchemeGroupVersion.WithKind(apps.ProjectConfigKind),
+	"application":         apps.SchemeGroupVersion.WithKind(apps.ApplicationKind),
+	"build":               apps.SchemeGroupVersion.WithKind(apps.BuildKind),
+	"release":             apps.SchemeGroupVersion.WithKind(apps.ReleaseKind),
+	"mysql":               storage.SchemeGroupVersion.WithKind(storage.MySQLKind),
+	"mysqldatabase":       storage.SchemeGroupVersion.WithKind(storage.MySQLDatabaseKind),
+	"postgres":            storage.SchemeGroupVersion.WithKind(storage.PostgresKind),
+	"postgresdatabase":    storage.SchemeGroupVersion.WithKind(storage.PostgresDatabaseKind),
+	"keyvaluestore":       storage.SchemeGroupVersion.WithKind(storage.KeyValueStoreKind),
+	"opensearch":          storage.SchemeGroupVersion.WithKind(storage.OpenSearchKind),
+	"bucket":              storage.SchemeGroupVersion.WithKind(storage.BucketKind),
+	"bucketuser":          storage.SchemeGroupVersion.WithKind(storage.BucketUserKind),
+	"cloudvirtualmachine": infrastructure.SchemeGroupVersion.WithKind(infrastructure.CloudVirtualMachineKind),
+	"serviceconnection":   networking.SchemeGroupVersion.WithKind(networking.ServiceConnectionKind),
+	"staticegress":        networking.SchemeGroupVersion.WithKind(networking.StaticEgressKind),
+	"grafana":             observability.SchemeGroupVersion.WithKind(observability.GrafanaKind),
+}
+
+// organizationKinds are the kinds which live in the namespace of the
+// organization instead of a project.
+var organizationKinds = []string{management.ProjectKind}
+
+type resourceCmd struct {
+	format.Writer `kong:"-"`
+	Name          string `arg:"" completion-predictor:"resource_name" help:"Name of the resource to describe." required:""`
+}
+
+// BeforeApply initializes Writer from Kong's bound [io.Writer].
+func (cmd *resourceCmd) BeforeApply(writer io.Writer) error {
+	return cmd.Writer.BeforeApply(writer)
+}
+
+// relatedLimit is the maximum amount of releases and builds which are shown
+// for an application.
+const relatedLimit = 3
+
+func (cmd *resourceCmd) Run(kong *kong.Context, ctx context.Context, c *api.Client) error {
+	gvk, ok := kinds[kong.Selected().Name]
+	if !ok {
+		return fmt.Errorf("no kind known for %s", kong.Selected().Name)
+	}
+	key := c.Name(cmd.Name)
+	if slices.Contains(organizationKinds, gvk.Kind) {
+		org, err := c.Organization()
+		if err != nil {
+			return err
+		}
+		key.Namespace = org
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := c.Get(ctx, key, obj); err != nil {
+		return err
+	}
+
+	d := &description{w: tabwriter.NewWriter(&cmd.Writer, 0, 0, 2, ' ', 0)}
+	d.overview(obj)
+	d.spec(obj)
+	conditions, err := objectConditions(obj)
+	if err != nil {
+		return err
+	}
+	d.conditions(conditions)
+	if err := d.connectionSecret(ctx, c, obj); err != nil {
+		return err
+	}
+	if err := d.events(ctx, c, obj); err != nil {
+		return err
+	}
+	if err := d.related(ctx, c, obj); err != nil {
+		return err
+	}
+	d.suggestions(suggestions(kong.Selected().Name, obj, conditions))
+
+	return d.w.Flush()
+}
+
+// description renders the sections of a describe output.
+type description struct {
+	w *tabwriter.Writer
+}
+
+func (d *description) section(title string) {
+	fmt.Fprintf(d.w, "\n%s:\n", title)
+}
+
+func (d *description) row(indent int, cells ...string) {
+	fmt.Fprintf(d.w, "%s%s\n", strings.Repeat("  ", indent), strings.Join(cells, "\t"))
+}
+
+func (d *description) overview(obj *unstructured.Unstructured) {
+	gvk := obj.GroupVersionKind()
+	d.row(0, "Name:", obj.GetName())
+	d.row(0, "Project:", obj.GetNamespace())
+	d.row(0, "Kind:", fmt.Sprintf("%s.%s", gvk.Kind, gvk.Group))
+	d.row(0, "Age:", age(obj.GetCreationTimestamp().Time))
+	if ts := obj.GetDeletionTimestamp(); ts != nil {
+		d.row(0, "Deleting since:", age(ts.Time))
+	}
+}
+
+// spec prints all scalar fields of the spec. Resources which are managed by
+// a provider keep the interesting fields in spec.forProvider, so we only
+// print those if they exist.
+func (d *description) spec(obj *unstructured.Unstructured) {
+	spec, found, _ := unstructured.NestedMap(obj.Object, "spec", "forProvider")
+	if !found {
+		spec, _, _ = unstructured.NestedMap(obj.Object, "spec")
+	}
+	fields := map[string]string{}
+	flatten("", spec, fields)
+
+	d.section("Spec")
+	if len(fields) == 0 {
+		d.row(1, "<none>")
+		return
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		d.row(1, k+":", fields[k])
+	}
+}
+
+// flatten collects all non-empty scalar values of the map into fields, keyed
+// by their dotted path. Lists of objects are summarized by their length.
+func flatten(prefix string, m map[string]any, fields map[string]string) {
+	for k, v := range m {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(path, v, fields)
+		case []any:
+			if len(v) == 0 {
+				continue
+			}
+			values := make([]string, 0, len(v))
+			for _, item := range v {
+				if _, isObject := item.(map[string]any); isObject {
+					values = nil
+					break
+				}
+				values = append(values, fmt.Sprint(item))
+			}
+			if values == nil {
+				fields[path] = fmt.Sprintf("<%d items>", len(v))
+				continue
+			}
+			fields[path] = strings.Join(values, ",")
+		case nil:
+		default:
+			if s := fmt.Sprint(v); s != "" {
+				fields[path] = s
+			}
+		}
+	}
+}
+
+func objectConditions(obj *unstructured.Unstructured) ([]runtimev1.Condition, error) {
+	raw, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return nil, err
+	}
+	status := struct {
+		Conditions []runtimev1.Condition `json:"conditions"`
+	}{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(
+		map[string]any{"conditions": raw}, &status,
+	); err != nil {
+		return nil, fmt.Errorf("unable to parse conditions: %w", err)
+	}
+	return status.Conditions, nil
+}
+
+func (d *description) conditions(conditions []runtimev1.Condition) {
+	d.section("Conditions")
+	if len(conditions) == 0 {
+		d.row(1, "<none>")
+		return
+	}
+	d.row(1, "TYPE", "STATUS", "REASON", "AGE", "MESSAGE")
+	for _, c := range conditions {
+		d.row(1, string(c.Type), string(c.Status), string(c.Reason), age(c.LastTransitionTime.Time), c.Message)
+	}
+}
+
+// connectionSecret prints whether the connection secret of the object
+// exists. Nothing is printed if the object does not write a connection
+// secret.
+func (d *description) connectionSecret(ctx context.Context, c *api.Client, obj *unstructured.Unstructured) error {
+	name, found, _ := unstructured.NestedString(obj.Object, "spec", "writeConnectionSecretToRef", "name")
+	if !found || name == "" {
+		return nil
+	}
+	namespace, _, _ := unstructured.NestedString(obj.Object, "spec", "writeConnectionSecretToRef", "namespace")
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+
+	d.section("Connection Secret")
+	state := "present"
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &corev1.Secret{}); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return fmt.Errorf("unable to get connection secret: %w", err)
+		}
+		state = "missing"
+	}
+	d.row(1, name+":", state)
+	return nil
+}
+
+func (d *description) events(ctx context.Context, c *api.Client, obj *unstructured.Unstructured) error {
+	events, err := objectEvents(ctx, c, obj)
+	if err != nil {
+		return err
+	}
+
+	d.section("Events")
+	if len(events) == 0 {
+		d.row(1, "<none>")
+		return nil
+	}
+	d.row(1, "TYPE", "REASON", "AGE", "COUNT", "MESSAGE")
+	for _, e := range events {
+		count := e.Count
+		if count == 0 {
+			count = 1
+		}
+		d.row(1, e.Type, e.Reason, age(api.EventTime(e)), fmt.Sprint(count), strings.TrimSpace(e.Message))
+	}
+	return nil
+}
+
+// objectEvents returns the events involving obj, oldest first.
+func objectEvents(ctx context.Context, c *api.Client, obj *unstructured.Unstructured) ([]corev1.Event, error) {
+	list := &corev1.EventList{}
+	if err := c.List(ctx, list,
+		runtimeclient.InNamespace(obj.GetNamespace()),
+		runtimeclient.MatchingFields{
+			api.EventObjectNameField: obj.GetName(),
+			api.EventObjectKindField: obj.GetKind(),
+		},
+	); err != nil {
+		if kerrors.IsForbidden(err) || kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to list events: %w", err)
+	}
+	events := list.Items
+	sort.SliceStable(events, func(i, j int) bool {
+		return api.EventTime(events[i]).Before(api.EventTime(events[j]))
+	})
+	return events, nil
+}
+
+// related prints resources which belong to or reference the object.
+func (d *description) related(ctx context.Context, c *api.Client, obj *unstructured.Unstructured) error {
+	rows := [][]string{}
+	if obj.GetKind() == apps.ApplicationKind && obj.GroupVersionKind().Group == apps.Group {
+		appRows, err := applicationRelated(ctx, c, api.ObjectName(obj))
+		if err != nil {
+			return err
+		}
+		rows = append(rows, appRows...)
+	}
+
+	connections := &networking.ServiceConnectionList{}
+	if err := c.List(ctx, connections, runtimeclient.InNamespace(obj.GetNamespace())); err != nil {
+		return fmt.Errorf("unable to list service connections: %w", err)
+	}
+	for _, sc := range connections.Items {
+		source := sc.Spec.ForProvider.Source.Reference
+		sourceNamespace := source.Namespace
+		if sourceNamespace == "" {
+			sourceNamespace = sc.Namespace
+		}
+		if source.Kind == obj.GetKind() && source.Name == obj.GetName() && sourceNamespace == obj.GetNamespace() {
+			rows = append(rows, []string{networking.ServiceConnectionKind, sc.Name, "to " + sc.Spec.ForProvider.Destination.String()})
+			continue
+		}
+		dest := sc.Spec.ForProvider.Destination
+		if dest.Kind == obj.GetKind() && dest.Name == obj.GetName() {
+			rows = append(rows, []string{networking.ServiceConnectionKind, sc.Name, "from " + source.String()})
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+	d.section("Related Resources")
+	d.row(1, "KIND", "NAME", "DETAILS")
+	for _, row := range rows {
+		d.row(1, row...)
+	}
+	return nil
+}
+
+func applicationRelated(ctx context.Context, c *api.Client, app types.NamespacedName) ([][]string, error) {
+	rows := [][]string{}
+	// Releases returns an error if there are no releases yet, which is
+	// expected for a new application.
+	if releases, err := application.Releases(ctx, c, app); err == nil {
+		application.OrderReleaseList(releases, false)
+		for _, r := range releases.Items[:min(relatedLimit, len(releases.Items))] {
+			rows = append(rows, []string{
+				apps.ReleaseKind, r.Name,
+				fmt.Sprintf("%s, %s old", r.Status.AtProvider.ReleaseStatus, age(r.CreationTimestamp.Time)),
+			})
+		}
+	}
+
+	builds := &apps.BuildList{}
+	if err := c.List(
+		ctx, builds,
+		runtimeclient.InNamespace(app.Namespace),
+		runtimeclient.MatchingLabels{application.ApplicationNameLabel: app.Name},
+	); err != nil {
+		return nil, fmt.Errorf("unable to list builds: %w", err)
+	}
+	sort.SliceStable(builds.Items, func(i, j int) bool {
+		return builds.Items[j].CreationTimestamp.Before(&builds.Items[i].CreationTimestamp)
+	})
+	for _, b := range builds.Items[:min(relatedLimit, len(builds.Items))] {
+		rows = append(rows, []string{
+			apps.BuildKind, b.Name,
+			fmt.Sprintf("%s, %s old", b.Status.AtProvider.BuildStatus, age(b.CreationTimestamp.Time)),
+		})
+	}
+
+	egresses, err := application.StaticEgresses(ctx, c, app)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list static egresses: %w", err)
+	}
+	for _, e := range egresses {
+		rows = append(rows, []string{networking.StaticEgressKind, e.Name, e.Status.AtProvider.Address})
+	}
+	return rows, nil
+}
+
+// suggestions returns suggested actions for conditions which indicate a
+// problem with the object. The command is the name of the get subcommand for
+// the kind of the object.
+func suggestions(command string, obj *unstructured.Unstructured, conditions []runtimev1.Condition) []string {
+	getCmd := format.Command().Get(command, obj.GetName())
+	result := []string{}
+	for _, c := range conditions {
+		if c.Status == corev1.ConditionTrue {
+			continue
+		}
+		switch c.Reason {
+		case runtimev1.ReasonReconcileError:
+			result = append(result, "The resource could not be reconciled, check the message of the "+string(c.Type)+" condition and the events above")
+		case runtimev1.ReasonReconcilePaused:
+			result = append(result, "Reconciliation is paused, remove the \"crossplane.io/paused\" annotation to resume it")
+		case runtimev1.ReasonCreating, runtimev1.ReasonUnavailable:
+			result = append(result, "The resource is not ready yet, follow its progress with `"+getCmd+" --watch`")
+		case runtimev1.ReasonDeleting:
+			result = append(result, "The resource is being deleted and will disappear once all its dependents are gone")
+		}
+	}
+	return slices.Compact(result)
+}
+
+func (d *description) suggestions(suggestions []string) {
+	if len(suggestions) == 0 {
+		return
+	}
+	d.section("Suggested actions")
+	for _, s := range suggestions {
+		d.row(1, "- "+s)
+	}
+}
+
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t))
+}
@@ -0,0 +1,279 @@
+package describe
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kong"
+	runtimev1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	management "github.com/ninech/apis/management/v1alpha1"
+	meta "github.com/ninech/apis/meta/v1alpha1"
+	networking "github.com/ninech/apis/networking/v1alpha1"
+	storage "github.com/ninech/apis/storage/v1alpha1"
+	"github.com/ninech/nctl/internal/application"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	now := metav1.Now()
+	appLabels := map[string]string{application.ApplicationNameLabel: "app"}
+	app := &apps.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: test.DefaultProject},
+		Spec: apps.ApplicationSpec{
+			ForProvider: apps.ApplicationParameters{
+				Git: apps.ApplicationGitConfig{
+					GitTarget: apps.GitTarget{URL: "https://github.com/ninech/sample"},
+				},
+			},
+		},
+	}
+	app.SetConditions(
+		runtimev1.ReconcileError(errors.New("invalid git url")),
+		runtimev1.Creating(),
+	)
+
+	appRef := meta.TypedReference{
+		Reference: meta.Reference{Name: "app", Namespace: test.DefaultProject},
+		GroupKind: metav1.GroupKind{Group: apps.Group, Kind: apps.ApplicationKind},
+	}
+	dbRef := meta.TypedReference{
+		Reference: meta.Reference{Name: "db", Namespace: test.DefaultProject},
+		GroupKind: metav1.GroupKind{Group: storage.Group, Kind: storage.MySQLKind},
+	}
+	connection := func(source, destination meta.TypedReference) *networking.ServiceConnection {
+		sc := &networking.ServiceConnection{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-to-db", Namespace: test.DefaultProject},
+		}
+		sc.Spec.ForProvider.Source.Reference = source
+		sc.Spec.ForProvider.Destination = destination
+		return sc
+	}
+	egress := &networking.StaticEgress{
+		ObjectMeta: metav1.ObjectMeta{Name: "egress", Namespace: test.DefaultProject},
+	}
+	egress.Spec.ForProvider.Target = meta.LocalTypedReference{
+		LocalReference: meta.LocalReference{Name: "app"},
+		GroupKind:      metav1.GroupKind{Group: apps.Group, Kind: apps.ApplicationKind},
+	}
+	mysqlWithSecret := &storage.MySQL{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: test.DefaultProject},
+	}
+	mysqlWithSecret.SetWriteConnectionSecretToReference(&runtimev1.SecretReference{
+		Name: "db-secret", Namespace: test.DefaultProject,
+	})
+
+	tests := map[string]struct {
+		command    string
+		name       string
+		objects    []client.Object
+		contains   []string
+		notContain []string
+		wantErr    bool
+	}{
+		"application": {
+			command: "application",
+			name:    "app",
+			objects: []client.Object{
+				app,
+				&apps.Release{
+					ObjectMeta: metav1.ObjectMeta{Name: "release-old", Namespace: test.DefaultProject, Labels: appLabels},
+				},
+				&apps.Release{
+					ObjectMeta:            metav1.ObjectMeta{Name: "release-new", Namespace: test.DefaultProject, Labels: appLabels},
+					CreationTimestampNano: 1,
+				},
+				&apps.Build{
+					ObjectMeta: metav1.ObjectMeta{Name: "build-1", Namespace: test.DefaultProject, Labels: appLabels},
+				},
+				egress,
+				connection(appRef, dbRef),
+				&corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: "app.1", Namespace: test.DefaultProject},
+					InvolvedObject: corev1.ObjectReference{Kind: apps.ApplicationKind, Name: "app"},
+					Type:           corev1.EventTypeWarning,
+					Reason:         "CannotCloneRepository",
+					Message:        "repository not found",
+					LastTimestamp:  now,
+				},
+				&corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: "other.1", Namespace: test.DefaultProject},
+					InvolvedObject: corev1.ObjectReference{Kind: apps.ApplicationKind, Name: "other"},
+					Reason:         "OtherReason",
+				},
+			},
+			contains: []string{
+				"Name:", "app",
+				"git.url:", "https://github.com/ninech/sample",
+				"Synced", "ReconcileError", "invalid git url",
+				"Ready", "Creating",
+				"CannotCloneRepository", "repository not found",
+				"release-new", "release-old", "build-1",
+				"StaticEgress", "egress",
+				"ServiceConnection", "app-to-db",
+				"Suggested actions:", "could not be reconciled", "get application app --watch",
+			},
+			notContain: []string{"OtherReason", "Connection Secret:"},
+		},
+		"connection secret": {
+			command: "mysql",
+			name:    "db",
+			objects: []client.Object{
+				mysqlWithSecret,
+			},
+			contains:   []string{"Connection Secret:", "db-secret:", "missing", "Conditions:", "<none>"},
+			notContain: []string{"Suggested actions:", "Related Resources:"},
+		},
+		"service connection destination": {
+			command: "mysql",
+			name:    "db",
+			objects: []client.Object{
+				&storage.MySQL{
+					ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: test.DefaultProject},
+				},
+				connection(meta.TypedReference{}, dbRef),
+			},
+			contains: []string{"Related Resources:", "app-to-db"},
+		},
+		"not found": {
+			command: "application",
+			name:    "missing",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t, test.WithObjects(tc.objects...))
+			buf := &bytes.Buffer{}
+			cmd := resourceCmd{Writer: format.NewWriter(buf), Name: tc.name}
+			err := cmd.Run(&kong.Context{
+				Path: []*kong.Path{{Command: &kong.Command{Name: tc.command}}},
+			}, t.Context(), apiClient)
+			if tc.wantErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+
+			out := buf.String()
+			for _, s := range tc.contains {
+				is.Contains(out, s)
+			}
+			for _, s := range tc.notContain {
+				is.NotContains(out, s)
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	fields := map[string]string{}
+	flatten("", map[string]any{
+		"size":     "mini",
+		"replicas": int64(2),
+		"empty":    "",
+		"none":     nil,
+		"git": map[string]any{
+			"url": "https://example.org",
+		},
+		"hosts": []any{"a.example.org", "b.example.org"},
+		"env":   []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}},
+	}, fields)
+
+	is.Equal(map[string]string{
+		"size":     "mini",
+		"replicas": "2",
+		"git.url":  "https://example.org",
+		"hosts":    "a.example.org,b.example.org",
+		"env":      "<2 items>",
+	}, fields)
+}
+
+func TestEventsOrder(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	newEvent := func(name string, ts time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: test.DefaultProject},
+			InvolvedObject: corev1.ObjectReference{Kind: apps.ApplicationKind, Name: "app"},
+			LastTimestamp:  metav1.NewTime(ts),
+		}
+	}
+	now := time.Now()
+	otherApp := newEvent("other-app", now)
+	otherApp.InvolvedObject.Name = "other"
+	otherKind := newEvent("other-kind", now)
+	otherKind.InvolvedObject.Kind = apps.ReleaseKind
+	apiClient := test.SetupClient(t, test.WithObjects(
+		newEvent("b", now),
+		newEvent("a", now.Add(-time.Hour)),
+		newEvent("c", now.Add(-time.Minute)),
+		otherApp,
+		otherKind,
+	))
+
+	app := &unstructured.Unstructured{}
+	app.SetKind(apps.ApplicationKind)
+	app.SetName("app")
+	app.SetNamespace(test.DefaultProject)
+	events, err := objectEvents(t.Context(), apiClient, app)
+	is.NoError(err)
+	is.Len(events, 3)
+	is.Equal([]string{"a", "c", "b"}, []string{events[0].Name, events[1].Name, events[2].Name})
+}
+
+func TestKinds(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	cmd := reflect.TypeFor[Cmd]()
+	for i := range cmd.NumField() {
+		name := cmd.Field(i).Tag.Get("name")
+		gvk, ok := kinds[name]
+		is.True(ok, "no kind for subcommand %s", name)
+		is.NotEmpty(gvk.Kind, name)
+	}
+	is.Len(kinds, cmd.NumField())
+}
+
+func TestDescribeProject(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	// projects live in the namespace of the organization, not in the
+	// current project.
+	const org = "acme"
+	apiClient := test.SetupClient(t,
+		test.WithOrganization(org),
+		test.WithProjects(org, "acme-web"),
+		test.WithDefaultProject("acme-web"),
+		test.WithKubeconfig(),
+	)
+	buf := &bytes.Buffer{}
+	cmd := resourceCmd{Writer: format.NewWriter(buf), Name: "acme-web"}
+	err := cmd.Run(&kong.Context{
+		Path: []*kong.Path{{Command: &kong.Command{Name: "project"}}},
+	}, t.Context(), apiClient)
+	is.NoError(err)
+	is.Contains(buf.String(), "acme-web")
+	is.Contains(buf.String(), management.ProjectKind)
+}
@@ -0,0 +1,7 @@
+// Package diff provides the implementation for the diff command, showing the
+// difference between the live resources and the ones defined in files.
+package diff
+
+type Cmd struct {
+	FromFile fromFile `cmd:"" default:"withargs" name:"-f <file>" help:"Diff any resource from a yaml or json file against the live state."`
+}
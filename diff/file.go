@@ -0,0 +1,214 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/apply"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/pmezard/go-difflib/difflib"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+type fromFile struct {
+	format.Writer       `hidden:""`
+	Filename            *os.File `short:"f" completion-predictor:"file"`
+	Recursive           bool     `short:"R" help:"Process the directory passed to -f recursively."`
+	apply.TemplateFlags `embed:""`
+}
+
+// Help displays examples for the diff command.
+func (cmd fromFile) Help() string {
+	return `The diff is computed with a server-side dry run, so it shows exactly what
+"nctl apply -f" would change. Fields which are managed by the server (status,
+managed fields, resource versions and finalizers) are not shown.
+
+Exit status: 0 if no differences were found, 1 if differences were found
+or an error occurred.
+
+Examples:
+  # Show what would change when applying a file
+  nctl diff -f app.yaml
+
+  # Show the changes for all manifests in a directory
+  nctl diff -f project/ -R
+
+  # Show the changes of a templated manifest
+  nctl diff -f app.yaml --values prod.yaml --set image.tag=v2
+`
+}
+
+// serverManagedFields are removed from both the live and the desired object
+// before they are compared.
+var serverManagedFields = [][]string{
+	{"status"},
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "uid"},
+	{"metadata", "generation"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "selfLink"},
+	{"metadata", "finalizers"},
+}
+
+func (cmd *fromFile) Run(ctx context.Context, client *api.Client) error {
+	if cmd.Filename == nil {
+		return fmt.Errorf("missing flag -f, --filename=STRING")
+	}
+	if cmd.RenderOnly {
+		opts, err := cmd.TemplateFlags.Options()
+		if err != nil {
+			return err
+		}
+		if cmd.Recursive {
+			opts = append(opts, apply.Recursive())
+		}
+		return apply.File(ctx, cmd.Writer, client, cmd.Filename, opts...)
+	}
+	defer cmd.Filename.Close()
+
+	values, err := cmd.TemplateFlags.LoadValues()
+	if err != nil {
+		return err
+	}
+	objs, err := apply.ReadObjects(cmd.Filename, cmd.Recursive, values)
+	if err != nil {
+		return err
+	}
+
+	differ, failed := 0, 0
+	for _, obj := range objs {
+		d, err := objectDiff(ctx, client, obj)
+		if err != nil {
+			cmd.Failuref("💥", "%s: %s", formatObj(obj), err)
+			failed++
+			continue
+		}
+		if d == "" {
+			continue
+		}
+		differ++
+		cmd.Printf("%s", colorize(d))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to diff %d of %d resources", failed, len(objs))
+	}
+	if differ > 0 {
+		return cli.ErrorWithContext(fmt.Errorf("%d of %d resources differ", differ, len(objs))).
+			WithExitCode(cli.ExitError)
+	}
+
+	cmd.Success("✅", "no differences found")
+	return nil
+}
+
+// objectDiff returns the unified diff between the live object and the
+// object which would be the result of applying obj. An empty string is
+// returned if there are no differences.
+func objectDiff(ctx context.Context, client *api.Client, obj *unstructured.Unstructured) (string, error) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := client.Get(ctx, api.ObjectName(obj), live); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return "", err
+		}
+		live = nil
+	}
+
+	desired := obj.DeepCopy()
+	if live == nil {
+		if err := client.Create(ctx, desired, runtimeclient.DryRunAll); err != nil {
+			return "", err
+		}
+	} else {
+		apply.MergeExisting(desired, live)
+		if err := client.Update(ctx, desired, runtimeclient.DryRunAll); err != nil {
+			return "", err
+		}
+	}
+
+	liveYAML, err := toYAML(client.Scheme(), live)
+	if err != nil {
+		return "", err
+	}
+	desiredYAML, err := toYAML(client.Scheme(), desired)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(liveYAML),
+		B:        difflib.SplitLines(desiredYAML),
+		FromFile: "live/" + objectPath(obj),
+		ToFile:   "desired/" + objectPath(obj),
+		Context:  3,
+	})
+}
+
+// toYAML normalizes obj and returns it as YAML. The object is converted to
+// its typed representation and back if the kind is known, so both sides of
+// the diff are serialized the same way. A nil obj results in an empty string.
+func toYAML(scheme *runtime.Scheme, obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+
+	content := obj.UnstructuredContent()
+	if typed, err := scheme.New(obj.GroupVersionKind()); err == nil {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, typed); err != nil {
+			return "", err
+		}
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(typed); err != nil {
+			return "", err
+		}
+	} else {
+		content = runtime.DeepCopyJSON(content)
+	}
+
+	for _, field := range serverManagedFields {
+		unstructured.RemoveNestedField(content, field...)
+	}
+
+	b, err := yaml.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// colorize colors the lines of a unified diff.
+func colorize(diff string) string {
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = color.New(color.Bold).Sprint(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = color.CyanString("%s", line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = color.GreenString("%s", line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = color.RedString("%s", line)
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func objectPath(obj *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+func formatObj(obj *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s %s/%s", obj.GetKind(), obj.GetName(), obj.GetNamespace())
+}
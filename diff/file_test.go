@@ -0,0 +1,122 @@
+package diff
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	runtimev1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	iam "github.com/ninech/apis/iam/v1alpha1"
+	"github.com/ninech/nctl/apply"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const asaYAML = `apiVersion: iam.nine.ch/v1alpha1
+kind: APIServiceAccount
+metadata:
+  name: foo
+  namespace: default
+spec:
+  deletionPolicy: Orphan
+`
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+
+	existing := &iam.APIServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   test.DefaultProject,
+			Annotations: map[string]string{cli.ManagedByAnnotation: cli.Name},
+			Finalizers:  []string{"finalizer.managedresource.crossplane.io"},
+		},
+	}
+	existing.SetDeletionPolicy(runtimev1.DeletionOrphan)
+
+	tests := map[string]struct {
+		file         string
+		set          []string
+		existing     []client.Object
+		wantDiff     bool
+		wantContains []string
+	}{
+		"no differences": {
+			file:         asaYAML,
+			existing:     []client.Object{existing},
+			wantContains: []string{"no differences found"},
+		},
+		"changed field": {
+			file:     strings.ReplaceAll(asaYAML, "Orphan", "Delete"),
+			existing: []client.Object{existing},
+			wantDiff: true,
+			wantContains: []string{
+				"--- live/APIServiceAccount/default/foo",
+				"+++ desired/APIServiceAccount/default/foo",
+				"-  deletionPolicy: Orphan",
+				"+  deletionPolicy: Delete",
+			},
+		},
+		"templated": {
+			file:     strings.ReplaceAll(asaYAML, "Orphan", "{{ .Values.policy }}"),
+			set:      []string{"policy=Delete"},
+			existing: []client.Object{existing},
+			wantDiff: true,
+			wantContains: []string{
+				"-  deletionPolicy: Orphan",
+				"+  deletionPolicy: Delete",
+			},
+		},
+		"new object": {
+			file:     asaYAML,
+			wantDiff: true,
+			wantContains: []string{
+				"+kind: APIServiceAccount",
+				"+  deletionPolicy: Orphan",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t, test.WithObjects(tc.existing...))
+
+			path := filepath.Join(t.TempDir(), "asa.yaml")
+			is.NoError(os.WriteFile(path, []byte(tc.file), 0o644))
+			f, err := os.Open(path)
+			is.NoError(err)
+
+			buf := &bytes.Buffer{}
+			cmd := &fromFile{
+				Writer:        format.NewWriter(buf),
+				Filename:      f,
+				TemplateFlags: apply.TemplateFlags{Set: tc.set},
+			}
+			err = cmd.Run(t.Context(), apiClient)
+			if tc.wantDiff {
+				cliErr, ok := errors.AsType[*cli.Error](err)
+				is.True(ok, "expected a cli.Error, got %v", err)
+				is.Equal(cli.ExitError, cliErr.ExitCode())
+			} else {
+				is.NoError(err)
+			}
+
+			for _, s := range tc.wantContains {
+				is.Contains(buf.String(), s)
+			}
+			if !tc.wantDiff {
+				is.NotContains(buf.String(), "deletionPolicy")
+			}
+		})
+	}
+}
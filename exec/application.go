@@ -9,10 +9,7 @@ import (
 	apps "github.com/ninech/apis/apps/v1alpha1"
 	"github.com/ninech/nctl/api"
 	"github.com/ninech/nctl/internal/application"
-	"github.com/ninech/nctl/internal/cli"
-	"github.com/ninech/nctl/internal/format"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
@@ -25,7 +22,7 @@ const (
 	appBuildTypeDockerfile appBuildType = "dockerfile"
 	// the launcher binary helps in setting up the application expected
 	// environment
-	buildpackEntrypoint    = "/cnb/lifecycle/launcher"
+	buildpackEntrypoint    = application.BuildpackEntrypoint
 	defaultShellBuildpack  = "/bin/bash"
 	defaultShellDockerfile = "/bin/sh"
 )
@@ -47,10 +44,14 @@ type remoteCommandParameters struct {
 
 type applicationCmd struct {
 	resourceCmd
-	Stdin     bool     `name:"stdin" short:"i" help:"Pass stdin to the application." default:"true"`
-	Tty       bool     `name:"tty" short:"t" help:"Stdin is a TTY." default:"true"`
-	WorkerJob string   `name:"worker-job" short:"w" help:"Exec into worker job by name."`
-	Command   []string `arg:"" help:"Command to execute." optional:""`
+	Stdin       bool     `name:"stdin" short:"i" help:"Pass stdin to the application." default:"true"`
+	Tty         bool     `name:"tty" short:"t" help:"Stdin is a TTY." default:"true"`
+	WorkerJob   string   `name:"worker-job" short:"w" help:"Exec into worker job by name."`
+	AllReplicas bool     `name:"all-replicas" help:"Run a non-interactive command on all ready replicas concurrently. Combined with --worker-job, all replicas of the worker job are used."`
+	Command     []string `arg:"" help:"Command to execute." optional:""`
+
+	// remoteCommand runs a command in a replica. nil means using exec.
+	remoteCommand func(ctx context.Context, params remoteCommandParameters) error `kong:"-"`
 }
 
 // Help displays examples for the application exec command
@@ -69,10 +70,20 @@ func (cmd applicationCmd) Help() string {
   # In certain situations it might be needed to not redirect stdin. This can be
   # achieved by using the "stdin" flag:
   nctl exec app --stdin=false myapp -- <command>
+
+  # Run a command on every replica of the application, e.g. to check that a
+  # file exists everywhere. The output is prefixed with the replica name.
+  nctl exec app myapp --all-replicas -- ls -l /tmp/cache
   `
 }
 
 func (cmd *applicationCmd) Run(ctx context.Context, client *api.Client, exec *Cmd) error {
+	// use dockerterm to gather the std io streams (windows supported)
+	stdin, stdout, stderr := dockerterm.StdStreams()
+	if cmd.AllReplicas {
+		return cmd.runAllReplicas(ctx, client, stdout, stderr)
+	}
+
 	replicaName, buildType, err := cmd.getReplica(ctx, client)
 	if err != nil {
 		return fmt.Errorf("error when searching for replica to connect: %w", err)
@@ -81,8 +92,6 @@ func (cmd *applicationCmd) Run(ctx context.Context, client *api.Client, exec *Cm
 	if err != nil {
 		return fmt.Errorf("can not create deplo.io cluster rest config: %w", err)
 	}
-	// use dockerterm to gather the std io streams (windows supported)
-	stdin, stdout, stderr := dockerterm.StdStreams()
 	return executeRemoteCommand(
 		ctx,
 		remoteCommandParameters{
@@ -100,7 +109,7 @@ func (cmd *applicationCmd) Run(ctx context.Context, client *api.Client, exec *Cm
 
 // getReplica finds a replica of the latest available release
 func (cmd *applicationCmd) getReplica(ctx context.Context, client *api.Client) (string, appBuildType, error) {
-	release, err := latestAvailableReleaseForApplication(ctx, client, client.Name(cmd.Name))
+	replica, release, err := application.Replica(ctx, client, client.Name(cmd.Name), cmd.WorkerJob)
 	if err != nil {
 		return "", "", err
 	}
@@ -108,45 +117,7 @@ func (cmd *applicationCmd) getReplica(ctx context.Context, client *api.Client) (
 	if release.Spec.ForProvider.DockerfileBuild {
 		buildType = appBuildTypeDockerfile
 	}
-	replicaObs := release.Status.AtProvider.ReplicaObservation
-
-	if cmd.WorkerJob != "" {
-		found := false
-		for _, wj := range release.Status.AtProvider.WorkerJobStatus {
-			if wj.Name == cmd.WorkerJob {
-				found = true
-				replicaObs = wj.ReplicaObservation
-			}
-		}
-		if !found {
-			availableJobs := make([]string, 0, len(release.Status.AtProvider.WorkerJobStatus))
-			for _, wj := range release.Status.AtProvider.WorkerJobStatus {
-				availableJobs = append(availableJobs, wj.Name)
-			}
-			return "", buildType, cli.ErrorWithContext(fmt.Errorf("worker job %q not found", cmd.WorkerJob)).
-				WithAvailable(availableJobs...).
-				WithSuggestions(
-					fmt.Sprintf("List worker jobs: %s", format.Command().Get(apps.ApplicationKind, cmd.Name, "-o", "yaml")),
-				)
-		}
-	}
-
-	if len(replicaObs) == 0 {
-		return "", buildType, fmt.Errorf("no replica information found for release %s", release.Name)
-	}
-	if replica := readyReplica(replicaObs); replica != "" {
-		return replica, buildType, nil
-	}
-	return "", buildType, fmt.Errorf("no ready replica found for release %s", release.Name)
-}
-
-func readyReplica(replicaObs []apps.ReplicaObservation) string {
-	for _, obs := range replicaObs {
-		if obs.Status == apps.ReplicaStatusReady {
-			return obs.ReplicaName
-		}
-	}
-	return ""
+	return replica, buildType, nil
 }
 
 // setupTTY sets up a TTY for command execution
@@ -237,21 +208,6 @@ func replicaCommand(buildType appBuildType, command []string) []string {
 	}
 }
 
-// latestAvailableReleaseForApplication returns the latest available release for a given application.
-func latestAvailableReleaseForApplication(ctx context.Context, client *api.Client, app types.NamespacedName) (*apps.Release, error) {
-	releases, err := application.Releases(ctx, client, app)
-	if err != nil {
-		return nil, err
-	}
-
-	release := application.LatestAvailableRelease(releases)
-	if release == nil {
-		return nil, fmt.Errorf("no ready release found for application %s", app.Name)
-	}
-
-	return release, nil
-}
-
 // newSizeQueue returns a [remotecommand.TerminalSizeQueue] that monitors terminal
 // size changes. It returns nil when the terminal cannot be monitored.
 func newSizeQueue(tty term.TTY) remotecommand.TerminalSizeQueue {
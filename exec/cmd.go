@@ -46,24 +46,32 @@ type accessManager[T resource.Managed] interface {
 	Update(ctx context.Context, client *api.Client, res T, cidrs []meta.IPv4CIDR) error
 }
 
-// serviceCmd is the shared base for all database exec sub-commands.
-type serviceCmd struct {
+// accessCmd is the shared base for all commands connecting to a service. It
+// holds the options to allow and wait for access to the service.
+type accessCmd struct {
 	resourceCmd
 	format.Writer `kong:"-"`
 	format.Reader `kong:"-"`
 	AllowedCidrs  *[]meta.IPv4CIDR `placeholder:"203.0.113.1/32" help:"Specifies the IP addresses allowed to connect to the instance. Overrides auto-detected public IP."`
 	WaitTimeout   time.Duration    `default:"3m" help:"Timeout waiting for connectivity."`
-	ExtraArgs     []string         `arg:"" optional:"" passthrough:"" help:"Additional flags passed to the CLI (after --)."`
 
 	// Internal dependencies — nil means use production default.
-	runCommand          func(cmd *exec.Cmd) error                                                                     `kong:"-"`
-	lookPath            func(file string) (string, error)                                                             `kong:"-"`
 	waitForConnectivity func(ctx context.Context, writer format.Writer, endpoint string, timeout time.Duration) error `kong:"-"`
 	openTTYForConfirm   func() (io.ReadCloser, error)                                                                 `kong:"-"`
 }
 
+// serviceCmd is the shared base for all database exec sub-commands.
+type serviceCmd struct {
+	accessCmd
+	ExtraArgs []string `arg:"" optional:"" passthrough:"" help:"Additional flags passed to the CLI (after --)."`
+
+	// Internal dependencies — nil means use production default.
+	runCommand func(cmd *exec.Cmd) error         `kong:"-"`
+	lookPath   func(file string) (string, error) `kong:"-"`
+}
+
 // BeforeApply initializes Writer and Reader from Kong's bound io.Writer and io.Reader.
-func (cmd *serviceCmd) BeforeApply(writer io.Writer, reader io.Reader) error {
+func (cmd *accessCmd) BeforeApply(writer io.Writer, reader io.Reader) error {
 	return errors.Join(
 		cmd.Writer.BeforeApply(writer),
 		cmd.Reader.BeforeApply(reader),
@@ -87,7 +95,7 @@ func (cmd serviceCmd) getLookPath() func(string) (string, error) {
 	return exec.LookPath
 }
 
-func (cmd serviceCmd) connectivityCheck() func(context.Context, format.Writer, string, time.Duration) error {
+func (cmd accessCmd) connectivityCheck() func(context.Context, format.Writer, string, time.Duration) error {
 	if cmd.waitForConnectivity != nil {
 		return cmd.waitForConnectivity
 	}
@@ -96,7 +104,7 @@ func (cmd serviceCmd) connectivityCheck() func(context.Context, format.Writer, s
 }
 
 // openTTY returns the openTTY function to use for confirming prompts.
-func (cmd serviceCmd) openTTY() func() (io.ReadCloser, error) {
+func (cmd accessCmd) openTTY() func() (io.ReadCloser, error) {
 	if cmd.openTTYForConfirm != nil {
 		return cmd.openTTYForConfirm
 	}
@@ -119,21 +127,8 @@ func connectAndExec[T resource.Managed](
 		return err
 	}
 
-	endpoint := connector.Endpoint(res)
-	if endpoint == "" {
-		return fmt.Errorf("resource %q is not ready yet (no endpoint available)", res.GetName())
-	}
-
-	if !quickDial(ctx, endpoint) {
-		if am, ok := connector.(accessManager[T]); ok {
-			if err := ensureAccess(ctx, client, am, res, opts); err != nil {
-				return err
-			}
-		}
-
-		if err := opts.connectivityCheck()(ctx, opts.Writer, endpoint, opts.WaitTimeout); err != nil {
-			return err
-		}
+	if _, err := connect(ctx, client, res, connector, opts.accessCmd); err != nil {
+		return err
 	}
 
 	user, pw, err := getCredentials(ctx, client, res)
@@ -163,6 +158,36 @@ func connectAndExec[T resource.Managed](
 	return nil
 }
 
+// connect returns the endpoint of res once it is reachable. If it is not
+// reachable right away, access is allowed for the caller if the connector
+// manages access and the endpoint is waited for.
+func connect[T resource.Managed](
+	ctx context.Context,
+	client *api.Client,
+	res T,
+	connector interface{ Endpoint(res T) string },
+	opts accessCmd,
+) (string, error) {
+	endpoint := connector.Endpoint(res)
+	if endpoint == "" {
+		return "", fmt.Errorf("resource %q is not ready yet (no endpoint available)", res.GetName())
+	}
+
+	if !quickDial(ctx, endpoint) {
+		if am, ok := connector.(accessManager[T]); ok {
+			if err := ensureAccess(ctx, client, am, res, opts); err != nil {
+				return "", err
+			}
+		}
+
+		if err := opts.connectivityCheck()(ctx, opts.Writer, endpoint, opts.WaitTimeout); err != nil {
+			return "", err
+		}
+	}
+
+	return endpoint, nil
+}
+
 // ensureAccess detects the caller's public IP (or uses the overridden list),
 // checks whether it is already permitted, and if not prompts the user before
 // calling connector.Update.
@@ -171,7 +196,7 @@ func ensureAccess[T resource.Managed](
 	client *api.Client,
 	connector accessManager[T],
 	res T,
-	cmd serviceCmd,
+	cmd accessCmd,
 ) error {
 	var toAdd []meta.IPv4CIDR
 
@@ -280,7 +305,7 @@ func (cmd serviceCmd) checkPath(name string) error {
 // confirm prints a confirmation prompt. When stdin is not a TTY it opens /dev/tty
 // so that piped input (e.g. SQL dumps) does not consume the prompt, mirroring
 // the pattern used by git and ssh.
-func (cmd serviceCmd) confirm(msg string) (bool, error) {
+func (cmd accessCmd) confirm(msg string) (bool, error) {
 	if !isatty.IsTerminal(os.Stdin.Fd()) {
 		tty, err := cmd.openTTY()()
 		if err == nil {
@@ -51,11 +51,7 @@ func testDatabaseCmdConfirmed(name string, cidrs *[]meta.IPv4CIDR, confirmed boo
 	}
 	cap := &capturingCmd{}
 	cmd := serviceCmd{
-		resourceCmd:  resourceCmd{Name: name},
-		Writer:       format.NewWriter(&bytes.Buffer{}),
-		Reader:       format.NewReader(reader),
-		AllowedCidrs: cidrs,
-		WaitTimeout:  0,
+		accessCmd: testAccessCmd(name, cidrs, reader),
 		runCommand: func(c *exec.Cmd) error {
 			cap.cmd = c
 			return nil
@@ -63,6 +59,19 @@ func testDatabaseCmdConfirmed(name string, cidrs *[]meta.IPv4CIDR, confirmed boo
 		lookPath: func(file string) (string, error) {
 			return "/usr/bin/" + file, nil
 		},
+	}
+	return cap, cmd
+}
+
+// testAccessCmd returns an accessCmd reading confirmations from reader which
+// does not wait for connectivity.
+func testAccessCmd(name string, cidrs *[]meta.IPv4CIDR, reader io.Reader) accessCmd {
+	return accessCmd{
+		resourceCmd:  resourceCmd{Name: name},
+		Writer:       format.NewWriter(&bytes.Buffer{}),
+		Reader:       format.NewReader(reader),
+		AllowedCidrs: cidrs,
+		WaitTimeout:  0,
 		waitForConnectivity: func(_ context.Context, _ format.Writer, _ string, _ time.Duration) error {
 			return nil
 		},
@@ -70,5 +79,4 @@ func testDatabaseCmdConfirmed(name string, cidrs *[]meta.IPv4CIDR, confirmed boo
 			return nil, fmt.Errorf("no tty in tests")
 		},
 	}
-	return cap, cmd
 }
@@ -0,0 +1,400 @@
+package exec
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+)
+
+// CopyCmd copies files and directories between the local machine and a
+// replica of a deplo.io application. The files are streamed as a tar archive
+// over an exec session, so the application image needs to contain tar.
+type CopyCmd struct {
+	format.Writer `kong:"-"`
+	Source        string `arg:"" help:"Path to copy from. Paths in an application replica are given as app:NAME:/path."`
+	Destination   string `arg:"" help:"Path to copy to. Paths in an application replica are given as app:NAME:/path."`
+	WorkerJob     string `name:"worker-job" short:"w" help:"Copy from or to a replica of the worker job with the given name."`
+
+	// remoteCommand runs a command in a replica. nil means using exec.
+	remoteCommand func(ctx context.Context, params remoteCommandParameters) error `kong:"-"`
+}
+
+// copyPath is a path on the local machine or, if app is set, in a replica of
+// the application.
+type copyPath struct {
+	app  string
+	path string
+}
+
+// copyStats counts the files and bytes copied.
+type copyStats struct {
+	files int
+	bytes int64
+}
+
+// BeforeApply initializes Writer from Kong's bound [io.Writer].
+func (cmd *CopyCmd) BeforeApply(writer io.Writer) error {
+	return cmd.Writer.BeforeApply(writer)
+}
+
+// Help displays examples for the cp command
+func (cmd CopyCmd) Help() string {
+	return `Examples:
+  # Download a heap dump of the application "myapp".
+  nctl cp app:myapp:/tmp/heap.hprof ./heap.hprof
+
+  # Upload the directory "fixtures" to /tmp/fixtures in the application.
+  nctl cp ./fixtures app:myapp:/tmp/fixtures
+
+  # Paths ending with a slash or existing local directories copy into the
+  # directory, like cp does. This creates ./reports/report.csv.
+  nctl cp app:myapp:/workspace/report.csv ./reports/
+
+  # Copy from a replica of the worker job "queue".
+  nctl cp --worker-job queue app:myapp:/tmp/out.log .
+  `
+}
+
+func (cmd *CopyCmd) Run(ctx context.Context, client *api.Client) error {
+	src, err := parseCopyPath(cmd.Source)
+	if err != nil {
+		return err
+	}
+	dest, err := parseCopyPath(cmd.Destination)
+	if err != nil {
+		return err
+	}
+	if (src.app == "") == (dest.app == "") {
+		return cli.ErrorWithContext(errors.New("exactly one of source and destination must be a path in an application")).
+			WithExitCode(cli.ExitUsageError).
+			WithSuggestions(
+				"Download a file: nctl cp app:myapp:/tmp/file ./file",
+				"Upload a file: nctl cp ./file app:myapp:/tmp/file",
+			)
+	}
+
+	app := src.app
+	if app == "" {
+		app = dest.app
+	}
+	exec := applicationCmd{resourceCmd: resourceCmd{Name: app}, WorkerJob: cmd.WorkerJob}
+	replica, buildType, err := exec.getReplica(ctx, client)
+	if err != nil {
+		return fmt.Errorf("error when searching for replica to connect: %w", err)
+	}
+	config, err := client.DeploioRuntimeConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("can not create deplo.io cluster rest config: %w", err)
+	}
+	params := remoteCommandParameters{
+		replicaName:      replica,
+		replicaNamespace: client.Project,
+		restConfig:       config,
+	}
+
+	progress, stop, err := cmd.progress()
+	if err != nil {
+		return err
+	}
+	var stats copyStats
+	if src.app != "" {
+		stats, err = cmd.download(ctx, params, buildType, src.path, dest.path, progress)
+	} else {
+		stats, err = cmd.upload(ctx, params, buildType, src.path, dest.path, progress)
+	}
+	stop()
+	if err != nil {
+		return err
+	}
+
+	cmd.Successf("📦", "copied %d files (%s) from %s to %s", stats.files, formatBytes(stats.bytes), cmd.Source, cmd.Destination)
+	return nil
+}
+
+// upload copies the local path src to dest in the replica.
+func (cmd *CopyCmd) upload(ctx context.Context, params remoteCommandParameters, buildType appBuildType, src, dest string, progress func(copyStats)) (copyStats, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return copyStats{}, err
+	}
+	if strings.HasSuffix(dest, "/") {
+		dest = path.Join(dest, filepath.Base(src))
+	}
+	dest = path.Clean(dest)
+	if info.IsDir() && (dest == "/" || dest == ".") {
+		return copyStats{}, fmt.Errorf("can not copy directory %s to %s, specify the target directory", src, dest)
+	}
+
+	reader, writer := io.Pipe()
+	type result struct {
+		stats copyStats
+		err   error
+	}
+	written := make(chan result, 1)
+	go func() {
+		stats, err := writeTar(writer, src, path.Base(dest), progress)
+		_ = writer.CloseWithError(err)
+		written <- result{stats: stats, err: err}
+	}()
+
+	params.command = replicaCommand(buildType, []string{"tar", "-xmf", "-", "-C", path.Dir(dest)})
+	params.enableStdin = true
+	params.stdin = reader
+	remoteErr := cmd.runRemote(ctx, params, io.Discard)
+	// unblock the tar writer in case the command stopped reading early.
+	_ = reader.CloseWithError(remoteErr)
+	res := <-written
+	if res.err != nil && !errors.Is(res.err, io.ErrClosedPipe) && (remoteErr == nil || !errors.Is(res.err, remoteErr)) {
+		return res.stats, res.err
+	}
+	return res.stats, remoteErr
+}
+
+// download copies the path src in the replica to the local path dest.
+func (cmd *CopyCmd) download(ctx context.Context, params remoteCommandParameters, buildType appBuildType, src, dest string, progress func(copyStats)) (copyStats, error) {
+	src = path.Clean(src)
+	base := path.Base(src)
+	if info, err := os.Stat(dest); (err == nil && info.IsDir()) || strings.HasSuffix(dest, string(filepath.Separator)) || strings.HasSuffix(dest, "/") {
+		dest = filepath.Join(dest, base)
+	}
+
+	reader, writer := io.Pipe()
+	errs := make(chan error, 1)
+	go func() {
+		params.command = replicaCommand(buildType, []string{"tar", "-cf", "-", "-C", path.Dir(src), base})
+		err := cmd.runRemote(ctx, params, writer)
+		_ = writer.CloseWithError(err)
+		errs <- err
+	}()
+
+	stats, err := extractTar(reader, dest, base, progress, cmd.Warningf)
+	// stop the command if the archive could not be extracted.
+	_ = reader.CloseWithError(err)
+	if remoteErr := <-errs; remoteErr != nil && (err == nil || errors.Is(err, remoteErr)) {
+		return stats, remoteErr
+	}
+	return stats, err
+}
+
+// runRemote runs the command of params in the replica. The error output of
+// the command is added to the returned error.
+func (cmd *CopyCmd) runRemote(ctx context.Context, params remoteCommandParameters, stdout io.Writer) error {
+	stderr := &bytes.Buffer{}
+	params.stdout = stdout
+	params.stderr = stderr
+
+	run := cmd.remoteCommand
+	if run == nil {
+		run = executeRemoteCommand
+	}
+	if err := run(ctx, params); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return cli.ErrorWithContext(fmt.Errorf("copying failed: %w", err)).
+			WithSuggestions("Copying requires tar to be available in the application image.")
+	}
+	return nil
+}
+
+// progress returns a function updating a spinner with the copy progress and
+// a function stopping the spinner. No spinner is shown when not printing to
+// a terminal.
+func (cmd *CopyCmd) progress() (func(copyStats), func(), error) {
+	if !format.IsInteractiveEnvironment(cmd.Writer.Writer) {
+		return func(copyStats) {}, func() {}, nil
+	}
+	spinner, err := cmd.Spinner(
+		format.Progress("📦", "copying"),
+		format.Progress("📦", "copied"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	_ = spinner.Start()
+	return func(stats copyStats) {
+			spinner.Message(format.Progressf("📦", "copied %d files (%s)", stats.files, formatBytes(stats.bytes)))
+		}, func() {
+			_ = spinner.Stop()
+		}, nil
+}
+
+// parseCopyPath parses a path argument of the cp command. Paths in a replica
+// are prefixed with app:NAME: or application:NAME:, all other paths are
+// local.
+func parseCopyPath(arg string) (copyPath, error) {
+	for _, prefix := range []string{"app:", "application:"} {
+		rest, found := strings.CutPrefix(arg, prefix)
+		if !found {
+			continue
+		}
+		app, p, found := strings.Cut(rest, ":")
+		if !found || app == "" || p == "" {
+			return copyPath{}, cli.ErrorWithContext(fmt.Errorf("invalid application path %q", arg)).
+				WithExitCode(cli.ExitUsageError).
+				WithSuggestions("Specify paths in an application as app:NAME:/path, e.g. app:myapp:/tmp/file")
+		}
+		return copyPath{app: app, path: p}, nil
+	}
+	return copyPath{path: arg}, nil
+}
+
+// writeTar writes the file or directory src to a tar archive, with the paths
+// in the archive starting with base.
+func writeTar(w io.Writer, src, base string, progress func(copyStats)) (copyStats, error) {
+	stats := copyStats{}
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		case !info.Mode().IsRegular() && !info.IsDir():
+			// sockets, devices and the like can not be copied.
+			return nil
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(base, filepath.ToSlash(rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		n, err := io.Copy(tw, f)
+		if err != nil {
+			return err
+		}
+		stats.files++
+		stats.bytes += n
+		progress(stats)
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	return stats, tw.Close()
+}
+
+// extractTar extracts the tar archive to dest. Only entries below base are
+// extracted, with base replaced by dest. Symbolic links and other special
+// files are skipped, as they could point outside of dest.
+func extractTar(r io.Reader, dest, base string, progress func(copyStats), warningf func(string, ...any)) (copyStats, error) {
+	stats := copyStats{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		rel, ok := trimBase(path.Clean(hdr.Name), base)
+		if !ok {
+			continue
+		}
+		if rel != "" && !filepath.IsLocal(rel) {
+			return stats, fmt.Errorf("archive contains invalid path %q", hdr.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, hdr.FileInfo().Mode().Perm()|0o700); err != nil {
+				return stats, err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return stats, err
+			}
+			n, err := writeFile(target, tr, hdr.FileInfo().Mode().Perm())
+			if err != nil {
+				return stats, err
+			}
+			stats.files++
+			stats.bytes += n
+			progress(stats)
+		default:
+			warningf("skipping %s as only regular files and directories are copied", hdr.Name)
+		}
+	}
+}
+
+// trimBase returns the name relative to base and whether name is base or
+// below it.
+func trimBase(name, base string) (string, bool) {
+	if base == "." || base == "/" {
+		return name, true
+	}
+	if name == base {
+		return "", true
+	}
+	rel, found := strings.CutPrefix(name, base+"/")
+	return rel, found
+}
+
+func writeFile(name string, r io.Reader, perm fs.FileMode) (int64, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. 1.5 MiB.
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,232 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	infrastructure "github.com/ninech/apis/infrastructure/v1alpha1"
+	meta "github.com/ninech/apis/meta/v1alpha1"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseCopyPath(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		arg         string
+		expected    copyPath
+		expectedErr bool
+	}{
+		"local":             {arg: "./heap.hprof", expected: copyPath{path: "./heap.hprof"}},
+		"app":               {arg: "app:myapp:/tmp/dump", expected: copyPath{app: "myapp", path: "/tmp/dump"}},
+		"application":       {arg: "application:myapp:report.csv", expected: copyPath{app: "myapp", path: "report.csv"}},
+		"colon in path":     {arg: "app:myapp:/tmp/a:b", expected: copyPath{app: "myapp", path: "/tmp/a:b"}},
+		"missing path":      {arg: "app:myapp", expectedErr: true},
+		"empty path":        {arg: "app:myapp:", expectedErr: true},
+		"missing app name":  {arg: "app::/tmp", expectedErr: true},
+		"local with colons": {arg: "./app:myapp", expected: copyPath{path: "./app:myapp"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			p, err := parseCopyPath(tc.arg)
+			if tc.expectedErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+			is.Equal(tc.expected, p)
+		})
+	}
+}
+
+// fakeTar runs the tar commands of the cp command against the directory root
+// instead of a replica.
+func fakeTar(t *testing.T, root string, commands *[][]string) func(context.Context, remoteCommandParameters) error {
+	return func(_ context.Context, params remoteCommandParameters) error {
+		*commands = append(*commands, params.command)
+		tar := slices.Index(params.command, "tar")
+		dir := params.command[slices.Index(params.command, "-C")+1]
+		switch params.command[tar+1] {
+		case "-xmf":
+			_, err := extractTar(params.stdin, filepath.Join(root, dir), ".", func(copyStats) {}, t.Logf)
+			return err
+		case "-cf":
+			base := params.command[len(params.command)-1]
+			src := filepath.Join(root, dir, base)
+			if _, err := os.Stat(src); err != nil {
+				_, _ = params.stderr.Write([]byte("tar: " + base + ": No such file or directory"))
+				return errors.New("command terminated with exit code 2")
+			}
+			_, err := writeTar(params.stdout, src, base, func(copyStats) {})
+			return err
+		}
+		return errors.New("unexpected command")
+	}
+}
+
+func TestCopy(t *testing.T) {
+	t.Parallel()
+
+	const app = "myapp"
+	objects := addCreationTimestamp([]apps.Release{
+		newRelease(app, []apps.ReplicaObservation{
+			{Status: apps.ReplicaStatusReady, ReplicaName: "replica-1"},
+		}, apps.ReleaseProcessStatusAvailable, false),
+	})
+	objects = append(objects, &infrastructure.ClusterData{
+		ObjectMeta: metav1.ObjectMeta{Name: meta.ClusterDataDeploioName},
+	})
+
+	writeFiles := func(t *testing.T, root string, files map[string]string) {
+		for name, content := range files {
+			p := filepath.Join(root, filepath.FromSlash(name))
+			require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+			require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		local, remote   map[string]string
+		source, dest    string
+		expectedLocal   map[string]string
+		expectedRemote  map[string]string
+		expectedCommand []string
+		expectedErr     bool
+	}{
+		"download file": {
+			remote:          map[string]string{"tmp/heap.hprof": "heap"},
+			source:          "app:myapp:/tmp/heap.hprof",
+			dest:            "dump.hprof",
+			expectedLocal:   map[string]string{"dump.hprof": "heap"},
+			expectedCommand: []string{buildpackEntrypoint, "tar", "-cf", "-", "-C", "/tmp", "heap.hprof"},
+		},
+		"download directory into existing directory": {
+			remote: map[string]string{"tmp/reports/a.csv": "a", "tmp/reports/daily/b.csv": "b"},
+			local:  map[string]string{"out/keep": "keep"},
+			source: "app:myapp:/tmp/reports",
+			dest:   "out",
+			expectedLocal: map[string]string{
+				"out/keep":                "keep",
+				"out/reports/a.csv":       "a",
+				"out/reports/daily/b.csv": "b",
+			},
+		},
+		"download missing file": {
+			source:      "app:myapp:/tmp/missing",
+			dest:        "missing",
+			expectedErr: true,
+		},
+		"upload file": {
+			local:           map[string]string{"fixture.json": "{}"},
+			source:          "fixture.json",
+			dest:            "app:myapp:/tmp/",
+			expectedRemote:  map[string]string{"tmp/fixture.json": "{}"},
+			expectedCommand: []string{buildpackEntrypoint, "tar", "-xmf", "-", "-C", "/tmp"},
+		},
+		"upload directory": {
+			local:          map[string]string{"fixtures/a.json": "a", "fixtures/nested/b.json": "b"},
+			source:         "fixtures",
+			dest:           "app:myapp:/tmp/data",
+			expectedRemote: map[string]string{"tmp/data/a.json": "a", "tmp/data/nested/b.json": "b"},
+		},
+		"upload missing file": {
+			source:      "missing",
+			dest:        "app:myapp:/tmp/missing",
+			expectedErr: true,
+		},
+		"both local": {
+			source:      "a",
+			dest:        "b",
+			expectedErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t,
+				test.WithKubeconfig(),
+				test.WithNameIndexFor(&apps.Release{}),
+				test.WithObjects(objects...),
+				test.WithDefaultProject(project),
+			)
+
+			local, remote := t.TempDir(), t.TempDir()
+			writeFiles(t, local, tc.local)
+			writeFiles(t, remote, tc.remote)
+			// local paths are relative to the local directory.
+			for _, p := range []*string{&tc.source, &tc.dest} {
+				if cp, err := parseCopyPath(*p); err == nil && cp.app == "" {
+					*p = filepath.Join(local, *p)
+				}
+			}
+
+			commands := [][]string{}
+			buf := &bytes.Buffer{}
+			cmd := CopyCmd{
+				Writer:        format.NewWriter(buf),
+				Source:        tc.source,
+				Destination:   tc.dest,
+				remoteCommand: fakeTar(t, remote, &commands),
+			}
+			err := cmd.Run(t.Context(), apiClient)
+			if tc.expectedErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+			is.Contains(buf.String(), "copied")
+
+			if tc.expectedCommand != nil {
+				is.Equal([][]string{tc.expectedCommand}, commands)
+			}
+			for name, content := range tc.expectedLocal {
+				b, err := os.ReadFile(filepath.Join(local, filepath.FromSlash(name)))
+				is.NoError(err)
+				is.Equal(content, string(b))
+			}
+			for name, content := range tc.expectedRemote {
+				b, err := os.ReadFile(filepath.Join(remote, filepath.FromSlash(name)))
+				is.NoError(err)
+				is.Equal(content, string(b))
+			}
+		})
+	}
+}
+
+func TestExtractTarRejectsEscapingPaths(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	src := t.TempDir()
+	is.NoError(os.WriteFile(filepath.Join(src, "evil"), []byte("evil"), 0o644))
+	buf := &bytes.Buffer{}
+	_, err := writeTar(buf, filepath.Join(src, "evil"), "../evil", func(copyStats) {})
+	is.NoError(err)
+
+	dest := t.TempDir()
+	_, err = extractTar(buf, filepath.Join(dest, "out"), ".", func(copyStats) {}, t.Logf)
+	is.Error(err)
+	_, err = os.Stat(filepath.Join(dest, "evil"))
+	is.True(os.IsNotExist(err))
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	is.Equal("512 B", formatBytes(512))
+	is.Equal("1.5 KiB", formatBytes(1536))
+	is.Equal("3.0 MiB", formatBytes(3*1024*1024))
+}
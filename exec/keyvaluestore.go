@@ -3,6 +3,7 @@ package exec
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os/exec"
 
 	meta "github.com/ninech/apis/meta/v1alpha1"
@@ -54,6 +55,20 @@ func (kvsConnector) Endpoint(kvs *storage.KeyValueStore) string {
 	return kvs.Status.AtProvider.FQDN + ":" + kvsPort
 }
 
+func (kvsConnector) CACert(kvs *storage.KeyValueStore) string {
+	return kvs.Status.AtProvider.CACert
+}
+
+// ProxyURL returns a redis connection URL for the proxy. The proxy
+// terminates TLS, so clients connect without it.
+func (kvsConnector) ProxyURL(addr, user, _ string) string {
+	return (&url.URL{Scheme: "redis", Host: addr, User: url.User(user)}).String()
+}
+
+// TerminateTLS is true as KeyValueStores only accept TLS connections, which
+// most clients need to be configured for explicitly.
+func (kvsConnector) TerminateTLS() bool { return true }
+
 func (kvsConnector) AllowedCIDRs(kvs *storage.KeyValueStore) []meta.IPv4CIDR {
 	return kvs.Spec.ForProvider.AllowedCIDRs
 }
@@ -3,6 +3,7 @@ package exec
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -68,6 +69,31 @@ func (mysqlConnector) Endpoint(my *storage.MySQL) string {
 	return my.Status.AtProvider.FQDN + ":" + mysqlPort
 }
 
+func (mysqlConnector) CACert(my *storage.MySQL) string {
+	return my.Status.AtProvider.CACert
+}
+
+// ProxyURL returns a MySQL connection URI for the proxy. The TLS connection
+// is passed through the proxy, so the server is verified with the CA only.
+func (c mysqlConnector) ProxyURL(addr, user, caPath string) string {
+	q := url.Values{}
+	if caPath != "" {
+		q.Set("ssl-mode", "VERIFY_CA")
+		q.Set("ssl-ca", caPath)
+	} else {
+		q.Set("ssl-mode", "REQUIRED")
+	}
+	return (&url.URL{
+		Scheme:   "mysql",
+		Host:     addr,
+		User:     url.User(user),
+		Path:     "/" + c.database,
+		RawQuery: q.Encode(),
+	}).String()
+}
+
+func (mysqlConnector) TerminateTLS() bool { return false }
+
 func (mysqlConnector) AllowedCIDRs(my *storage.MySQL) []meta.IPv4CIDR {
 	return my.Spec.ForProvider.AllowedCIDRs
 }
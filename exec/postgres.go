@@ -67,6 +67,18 @@ func (postgresConnector) Endpoint(pg *storage.Postgres) string {
 	return net.JoinHostPort(pg.Status.AtProvider.FQDN, postgresPort)
 }
 
+func (postgresConnector) CACert(pg *storage.Postgres) string {
+	return pg.Status.AtProvider.CACert
+}
+
+// ProxyURL returns a psql connection URL for the proxy. The TLS connection
+// is passed through the proxy, so the server is verified with the CA only.
+func (c postgresConnector) ProxyURL(addr, user, caPath string) string {
+	return postgresConnectionURL(addr, user, c.database, caPath).String()
+}
+
+func (postgresConnector) TerminateTLS() bool { return false }
+
 func (postgresConnector) AllowedCIDRs(pg *storage.Postgres) []meta.IPv4CIDR {
 	return pg.Spec.ForProvider.AllowedCIDRs
 }
@@ -0,0 +1,313 @@
+package exec
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	storage "github.com/ninech/apis/storage/v1alpha1"
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProxyCmd holds all proxy sub-commands.
+type ProxyCmd struct {
+	Postgres      postgresProxyCmd `cmd:"" group:"storage.nine.ch" name:"postgres" help:"Forward a local port to a PostgreSQL instance."`
+	MySQL         mysqlProxyCmd    `cmd:"" group:"storage.nine.ch" name:"mysql" help:"Forward a local port to a MySQL instance."`
+	KeyValueStore kvsProxyCmd      `cmd:"" group:"storage.nine.ch" name:"keyvaluestore" aliases:"kvs" help:"Forward a local port to a KeyValueStore instance."`
+}
+
+// proxyConnector is implemented by the connectors of services which can be
+// reached through the proxy.
+type proxyConnector[T resource.Managed] interface {
+	Endpoint(res T) string
+
+	// CACert returns the base64 encoded PEM CA certificate of res.
+	CACert(res T) string
+
+	// ProxyURL returns the URL clients use to connect through the proxy
+	// listening on addr. caPath is the path of the written CA certificate.
+	ProxyURL(addr, user, caPath string) string
+
+	// TerminateTLS reports whether the proxy connects to the endpoint with
+	// TLS and accepts plain connections locally. Services negotiating TLS
+	// within their protocol are passed through unchanged.
+	TerminateTLS() bool
+}
+
+// proxyCmd is the shared base for all proxy sub-commands.
+type proxyCmd struct {
+	accessCmd
+	Port    uint16 `short:"p" help:"Local port to listen on. 0 chooses a free port."`
+	Address string `default:"localhost" help:"Local address to listen on."`
+
+	// listening is called with the address of the listener once the proxy
+	// accepts connections.
+	listening func(addr net.Addr) `kong:"-"`
+}
+
+type postgresProxyCmd struct {
+	proxyCmd
+	Database string `name:"database" short:"d" default:"postgres" completion-predictor:"postgres_databases" help:"Database name used in the printed connection URL."`
+}
+
+// Help displays usage examples for the postgres proxy command.
+func (cmd postgresProxyCmd) Help() string {
+	return `The proxy forwards connections to localhost, so GUI tools and ORMs can
+connect to the instance. TLS is negotiated by the client, which can verify
+the server with the printed CA certificate. The proxy runs until it is
+interrupted.
+
+Examples:
+  # Forward local port 15432 to a PostgreSQL instance
+  nctl proxy postgres myinstance --port 15432
+
+  # Print the password to connect with
+  nctl get postgres myinstance --print-password
+`
+}
+
+func (cmd *postgresProxyCmd) Run(ctx context.Context, client *api.Client) error {
+	pg := &storage.Postgres{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cmd.Name,
+			Namespace: client.Project,
+		},
+	}
+	if err := client.Get(ctx, client.Name(cmd.Name), pg); err != nil {
+		return fmt.Errorf("getting postgres %q: %w", cmd.Name, err)
+	}
+	return runProxy(ctx, client, pg, postgresConnector{database: cmd.Database},
+		format.Command().Get("postgres", cmd.Name, "--print-password"), cmd.proxyCmd)
+}
+
+type mysqlProxyCmd struct {
+	proxyCmd
+	Database string `name:"database" short:"d" completion-predictor:"mysql_databases" help:"Database name used in the printed connection URL."`
+}
+
+// Help displays usage examples for the mysql proxy command.
+func (cmd mysqlProxyCmd) Help() string {
+	return `The proxy forwards connections to localhost, so GUI tools and ORMs can
+connect to the instance. TLS is negotiated by the client, which can verify
+the server with the printed CA certificate. The proxy runs until it is
+interrupted.
+
+Examples:
+  # Forward local port 13306 to a MySQL instance
+  nctl proxy mysql myinstance --port 13306
+
+  # Print the password to connect with
+  nctl get mysql myinstance --print-password
+`
+}
+
+func (cmd *mysqlProxyCmd) Run(ctx context.Context, client *api.Client) error {
+	my := &storage.MySQL{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cmd.Name,
+			Namespace: client.Project,
+		},
+	}
+	if err := client.Get(ctx, client.Name(cmd.Name), my); err != nil {
+		return fmt.Errorf("getting mysql %q: %w", cmd.Name, err)
+	}
+	return runProxy(ctx, client, my, mysqlConnector{database: cmd.Database},
+		format.Command().Get("mysql", cmd.Name, "--print-password"), cmd.proxyCmd)
+}
+
+type kvsProxyCmd struct {
+	proxyCmd
+}
+
+// Help displays usage examples for the keyvaluestore proxy command.
+func (cmd kvsProxyCmd) Help() string {
+	return `The proxy forwards connections to localhost, so GUI tools and clients can
+connect to the instance. The proxy verifies the instance and connects to it
+with TLS, so clients connect without TLS. The proxy runs until it is
+interrupted.
+
+Examples:
+  # Forward local port 16379 to a KeyValueStore instance
+  nctl proxy keyvaluestore mykvs --port 16379
+
+  # Print the password to connect with
+  nctl get keyvaluestore mykvs --print-token
+`
+}
+
+func (cmd *kvsProxyCmd) Run(ctx context.Context, client *api.Client) error {
+	kvs := &storage.KeyValueStore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cmd.Name,
+			Namespace: client.Project,
+		},
+	}
+	if err := client.Get(ctx, client.Name(cmd.Name), kvs); err != nil {
+		return fmt.Errorf("getting keyvaluestore %q: %w", cmd.Name, err)
+	}
+	return runProxy(ctx, client, kvs, kvsConnector{},
+		format.Command().Get("keyvaluestore", cmd.Name, "--print-token"), cmd.proxyCmd)
+}
+
+// runProxy allows access to res if needed and forwards connections to a local
+// listener to its endpoint until ctx is done. printPassword is the command
+// printing the password, which is not shown by the proxy.
+func runProxy[T resource.Managed](
+	ctx context.Context,
+	client *api.Client,
+	res T,
+	connector proxyConnector[T],
+	printPassword string,
+	opts proxyCmd,
+) error {
+	endpoint, err := connect(ctx, client, res, connector, opts.accessCmd)
+	if err != nil {
+		return err
+	}
+
+	user, _, err := getCredentials(ctx, client, res)
+	if err != nil {
+		return err
+	}
+
+	var tlsConfig *tls.Config
+	if connector.TerminateTLS() {
+		tlsConfig, err = upstreamTLSConfig(endpoint, connector.CACert(res))
+		if err != nil {
+			return err
+		}
+	}
+
+	// the CA certificate is kept for clients until the proxy stops.
+	dir, cleanup, err := createTempDir()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	caPath, err := writeCACert(dir, connector.CACert(res))
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(opts.Address, strconv.Itoa(int(opts.Port))))
+	if err != nil {
+		return cli.ErrorWithContext(fmt.Errorf("listening on %s: %w", opts.Address, err)).
+			WithExitCode(cli.ExitError).
+			WithSuggestions("Choose another local port with --port.")
+	}
+
+	opts.Successf("🔌", "forwarding %s to %s", listener.Addr(), endpoint)
+	opts.Infof("🔗", "connect with %s", connector.ProxyURL(listener.Addr().String(), user, caPath))
+	if caPath != "" && !connector.TerminateTLS() {
+		opts.Infof("🔒", "CA certificate: %s", caPath)
+	}
+	opts.Infof("🔑", "print the password with %s", printPassword)
+	opts.Infof("⏹️", "press Ctrl+C to stop the proxy")
+	if opts.listening != nil {
+		opts.listening(listener.Addr())
+	}
+
+	return serveProxy(ctx, listener, endpoint, tlsConfig, opts.Writer)
+}
+
+// upstreamTLSConfig returns the TLS config to connect to endpoint, verifying
+// it with the base64 encoded PEM CA certificate caCert. The system roots are
+// used if caCert is empty.
+func upstreamTLSConfig(endpoint, caCert string) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	if caCert == "" {
+		return config, nil
+	}
+
+	pem, err := base64.StdEncoding.DecodeString(caCert)
+	if err != nil {
+		return nil, fmt.Errorf("decoding CA certificate: %w", err)
+	}
+	config.RootCAs = x509.NewCertPool()
+	if !config.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.New("CA certificate contains no valid certificates")
+	}
+	return config, nil
+}
+
+// serveProxy accepts connections on listener and forwards each of them to
+// endpoint, using TLS if tlsConfig is set. It closes the listener and returns
+// once ctx is done.
+func serveProxy(ctx context.Context, listener net.Listener, endpoint string, tlsConfig *tls.Config, w format.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("accepting connection: %w", err)
+		}
+		wg.Go(func() {
+			if err := forward(ctx, conn, endpoint, tlsConfig); err != nil {
+				w.Warningf("connection from %s: %s", conn.RemoteAddr(), err)
+			}
+		})
+	}
+}
+
+// forward copies data between conn and a new connection to endpoint until
+// either side closes its connection or ctx is done.
+func forward(ctx context.Context, conn net.Conn, endpoint string, tlsConfig *tls.Config) error {
+	defer conn.Close()
+
+	var upstream net.Conn
+	var err error
+	if tlsConfig != nil {
+		upstream, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", endpoint)
+	} else {
+		upstream, err = (&net.Dialer{}).DialContext(ctx, "tcp", endpoint)
+	}
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", endpoint, err)
+	}
+	defer upstream.Close()
+
+	errs := make(chan error, 2)
+	copyConn := func(dst, src net.Conn) {
+		_, err := io.Copy(dst, src)
+		errs <- err
+	}
+	go copyConn(upstream, conn)
+	go copyConn(conn, upstream)
+
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+	}
+	// closing both connections ends the other copy.
+	_ = conn.Close()
+	_ = upstream.Close()
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
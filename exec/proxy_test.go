@@ -0,0 +1,190 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	meta "github.com/ninech/apis/meta/v1alpha1"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+)
+
+// echoServer accepts connections on a local port and writes back everything
+// it reads, using TLS if config is set. It returns the address of the server.
+func echoServer(t *testing.T, config *tls.Config) string {
+	var listener net.Listener
+	var err error
+	if config != nil {
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", config)
+	} else {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// selfSignedCert returns a certificate for localhost and its base64 encoded
+// PEM, as found in the status of storage resources.
+func selfSignedCert(t *testing.T) (tls.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return cert, base64.StdEncoding.EncodeToString(caPEM)
+}
+
+func TestServeProxy(t *testing.T) {
+	t.Parallel()
+
+	cert, caCert := selfSignedCert(t)
+	for name, tc := range map[string]struct {
+		tls         bool
+		caCert      string
+		expectedErr bool
+	}{
+		"plain":          {},
+		"terminates tls": {tls: true, caCert: caCert},
+		"unknown ca": {
+			tls:         true,
+			expectedErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			var serverConfig, clientConfig *tls.Config
+			if tc.tls {
+				serverConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+			}
+			endpoint := echoServer(t, serverConfig)
+			if tc.tls {
+				_, port, err := net.SplitHostPort(endpoint)
+				is.NoError(err)
+				// the certificate is valid for localhost only.
+				endpoint = net.JoinHostPort("localhost", port)
+				clientConfig, err = upstreamTLSConfig(endpoint, tc.caCert)
+				is.NoError(err)
+			}
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			is.NoError(err)
+			ctx, cancel := context.WithCancel(t.Context())
+			buf := &bytes.Buffer{}
+			done := make(chan error, 1)
+			go func() {
+				done <- serveProxy(ctx, listener, endpoint, clientConfig, format.NewWriter(buf))
+			}()
+
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			is.NoError(err)
+			_, err = conn.Write([]byte("PING\n"))
+			is.NoError(err)
+			is.NoError(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+			reply := make([]byte, 5)
+			_, err = io.ReadFull(conn, reply)
+			if tc.expectedErr {
+				is.Error(err)
+			} else {
+				is.NoError(err)
+				is.Equal("PING\n", string(reply))
+			}
+			is.NoError(conn.Close())
+
+			cancel()
+			is.NoError(<-done)
+			if tc.expectedErr {
+				is.Contains(buf.String(), "certificate")
+			}
+		})
+	}
+}
+
+func TestUpstreamTLSConfig(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	_, caCert := selfSignedCert(t)
+	config, err := upstreamTLSConfig("mykvs.example.com:6379", caCert)
+	is.NoError(err)
+	is.Equal("mykvs.example.com", config.ServerName)
+	is.NotNil(config.RootCAs)
+
+	config, err = upstreamTLSConfig("mykvs.example.com:6379", "")
+	is.NoError(err)
+	is.Nil(config.RootCAs)
+
+	_, err = upstreamTLSConfig("mykvs.example.com:6379", base64.StdEncoding.EncodeToString([]byte("no pem")))
+	is.Error(err)
+}
+
+func TestProxyNotReady(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	notReady := test.Postgres("notready", test.DefaultProject, "nine-es34")
+	apiClient := test.SetupClient(t, test.WithObjects(notReady))
+
+	called := false
+	cmd := postgresProxyCmd{proxyCmd: proxyCmd{
+		accessCmd: testAccessCmd("notready", &[]meta.IPv4CIDR{"203.0.113.5/32"}, strings.NewReader("")),
+		Address:   "127.0.0.1",
+		listening: func(net.Addr) { called = true },
+	}}
+	err := cmd.Run(t.Context(), apiClient)
+	is.ErrorContains(err, "not ready")
+	is.False(called)
+}
+
+func TestProxyURL(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	is.Equal("postgres://admin@localhost:15432/mydb?sslmode=verify-ca&sslrootcert=%2Ftmp%2Fca.pem",
+		postgresConnector{database: "mydb"}.ProxyURL("localhost:15432", "admin", "/tmp/ca.pem"))
+	is.Equal("mysql://admin@localhost:13306/?ssl-ca=%2Ftmp%2Fca.pem&ssl-mode=VERIFY_CA",
+		mysqlConnector{}.ProxyURL("localhost:13306", "admin", "/tmp/ca.pem"))
+	is.Equal("redis://default@localhost:16379",
+		kvsConnector{}.ProxyURL("localhost:16379", "default", "/tmp/ca.pem"))
+}
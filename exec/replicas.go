@@ -0,0 +1,159 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/liggitt/tabwriter"
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/application"
+	"github.com/ninech/nctl/internal/cli"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// replicaResult is the outcome of running a command in a replica.
+type replicaResult struct {
+	replica string
+	err     error
+}
+
+// runAllReplicas runs the command on all ready replicas concurrently. The
+// output of every replica is prefixed with its name and a summary of the exit
+// codes is printed once all commands are done.
+func (cmd *applicationCmd) runAllReplicas(ctx context.Context, client *api.Client, stdout, stderr io.Writer) error {
+	if len(cmd.Command) == 0 {
+		return cli.ErrorWithContext(errors.New("a command is required when running on all replicas")).
+			WithExitCode(cli.ExitUsageError).
+			WithSuggestions(fmt.Sprintf("Pass the command after --: nctl exec app %s --all-replicas -- <command>", cmd.Name))
+	}
+
+	replicas, buildType, err := cmd.getReplicas(ctx, client)
+	if err != nil {
+		return fmt.Errorf("error when searching for replicas to connect: %w", err)
+	}
+	config, err := client.DeploioRuntimeConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("can not create deplo.io cluster rest config: %w", err)
+	}
+
+	run := cmd.remoteCommand
+	if run == nil {
+		run = executeRemoteCommand
+	}
+	width := 0
+	for _, replica := range replicas {
+		width = max(width, len(replica))
+	}
+
+	// all writers share a mutex so the lines of the replicas do not interleave.
+	mu := &sync.Mutex{}
+	results := make([]replicaResult, len(replicas))
+	wg := sync.WaitGroup{}
+	for i, replica := range replicas {
+		wg.Go(func() {
+			prefix := fmt.Sprintf("%-*s | ", width, replica)
+			out := &prefixWriter{mu: mu, out: stdout, prefix: prefix}
+			errOut := &prefixWriter{mu: mu, out: stderr, prefix: prefix}
+			err := run(ctx, remoteCommandParameters{
+				replicaName:      replica,
+				replicaNamespace: client.Project,
+				command:          replicaCommand(buildType, cmd.Command),
+				stdout:           out,
+				stderr:           errOut,
+				restConfig:       config,
+			})
+			results[i] = replicaResult{replica: replica, err: errors.Join(err, out.Flush(), errOut.Flush())}
+		})
+	}
+	wg.Wait()
+
+	failed, err := printReplicaResults(stdout, results)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return cli.ErrorWithContext(fmt.Errorf("command failed on %d of %d replicas", failed, len(replicas))).
+			WithExitCode(cli.ExitError)
+	}
+	return nil
+}
+
+// getReplicas finds all ready replicas of the latest available release
+func (cmd *applicationCmd) getReplicas(ctx context.Context, client *api.Client) ([]string, appBuildType, error) {
+	replicas, release, err := application.Replicas(ctx, client, client.Name(cmd.Name), cmd.WorkerJob)
+	if err != nil {
+		return nil, "", err
+	}
+	buildType := appBuildTypeBuildpack
+	if release.Spec.ForProvider.DockerfileBuild {
+		buildType = appBuildTypeDockerfile
+	}
+	return replicas, buildType, nil
+}
+
+// printReplicaResults prints a table of the exit codes of the replicas and
+// returns the number of replicas where the command failed.
+func printReplicaResults(w io.Writer, results []replicaResult) (int, error) {
+	failed := 0
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(tw, "\nREPLICA\tEXIT CODE\tERROR\n")
+	for _, r := range results {
+		code, msg := "0", ""
+		if r.err != nil {
+			failed++
+			// errors not caused by the command, e.g. connection
+			// issues, have no exit code.
+			code, msg = "-", r.err.Error()
+			if exitErr, ok := errors.AsType[utilexec.ExitError](r.err); ok {
+				code, msg = fmt.Sprint(exitErr.ExitStatus()), ""
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.replica, code, msg)
+	}
+	return failed, tw.Flush()
+}
+
+// prefixWriter writes complete lines to out, each prefixed with prefix.
+// Incomplete lines are buffered until they are completed or flushed.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush writes a remaining incomplete line.
+func (w *prefixWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := append(w.buf, '\n')
+	w.buf = nil
+	return w.writeLine(line)
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+	return err
+}
@@ -0,0 +1,189 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	infrastructure "github.com/ninech/apis/infrastructure/v1alpha1"
+	meta "github.com/ninech/apis/meta/v1alpha1"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+func TestAllReplicas(t *testing.T) {
+	t.Parallel()
+
+	const app = "myapp"
+	release := newRelease(app, []apps.ReplicaObservation{
+		{Status: apps.ReplicaStatusReady, ReplicaName: "web-1"},
+		{Status: apps.ReplicaStatusFailing, ReplicaName: "web-2"},
+		{Status: apps.ReplicaStatusReady, ReplicaName: "web-long-3"},
+	}, apps.ReleaseProcessStatusAvailable, false)
+	release.Status.AtProvider.WorkerJobStatus = []apps.WorkerJobStatus{{
+		Name: "queue",
+		ReplicaObservation: []apps.ReplicaObservation{
+			{Status: apps.ReplicaStatusReady, ReplicaName: "queue-1"},
+		},
+	}}
+	objects := addCreationTimestamp([]apps.Release{release})
+	objects = append(objects, &infrastructure.ClusterData{
+		ObjectMeta: metav1.ObjectMeta{Name: meta.ClusterDataDeploioName},
+	})
+
+	for name, tc := range map[string]struct {
+		command          []string
+		workerJob        string
+		results          map[string]error
+		expectedReplicas []string
+		expectedStdout   []string
+		expectedStderr   []string
+		expectedErr      bool
+		expectedCode     int
+	}{
+		"all succeed": {
+			command:          []string{"ls", "/tmp/cache"},
+			expectedReplicas: []string{"web-1", "web-long-3"},
+			expectedStdout: []string{
+				"web-1      | ls /tmp/cache on web-1",
+				"web-1      | no newline",
+				"web-long-3 | ls /tmp/cache on web-long-3",
+				"web-long-3 | no newline",
+				"REPLICA      EXIT CODE   ERROR",
+				"web-1        0",
+				"web-long-3   0",
+			},
+		},
+		"one fails": {
+			command:          []string{"flush"},
+			expectedReplicas: []string{"web-1", "web-long-3"},
+			results: map[string]error{
+				"web-long-3": utilexec.CodeExitError{Err: errors.New("command terminated with exit code 2"), Code: 2},
+			},
+			expectedStdout: []string{
+				"web-1        0",
+				"web-long-3   2",
+			},
+			expectedStderr: []string{"web-long-3 | failed on web-long-3"},
+			expectedErr:    true,
+			expectedCode:   cli.ExitError,
+		},
+		"connection error": {
+			command:          []string{"flush"},
+			expectedReplicas: []string{"web-1", "web-long-3"},
+			results:          map[string]error{"web-1": errors.New("connection refused")},
+			expectedStdout: []string{
+				"web-1        -           connection refused",
+			},
+			expectedErr:  true,
+			expectedCode: cli.ExitError,
+		},
+		"worker job": {
+			command:          []string{"flush"},
+			workerJob:        "queue",
+			expectedReplicas: []string{"queue-1"},
+			expectedStdout: []string{
+				"queue-1 | flush on queue-1",
+				"queue-1   0",
+			},
+		},
+		"no command": {
+			expectedErr:  true,
+			expectedCode: cli.ExitUsageError,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t,
+				test.WithKubeconfig(),
+				test.WithNameIndexFor(&apps.Release{}),
+				test.WithObjects(objects...),
+				test.WithDefaultProject(project),
+			)
+
+			mu := sync.Mutex{}
+			commands := map[string]remoteCommandParameters{}
+			cmd := applicationCmd{
+				resourceCmd: resourceCmd{Name: app},
+				WorkerJob:   tc.workerJob,
+				AllReplicas: true,
+				Command:     tc.command,
+				remoteCommand: func(_ context.Context, params remoteCommandParameters) error {
+					mu.Lock()
+					commands[params.replicaName] = params
+					mu.Unlock()
+
+					cmdline := strings.Join(tc.command, " ")
+					if err := tc.results[params.replicaName]; err != nil {
+						fmt.Fprintf(params.stderr, "failed on %s\n", params.replicaName)
+						return err
+					}
+					fmt.Fprintf(params.stdout, "%s on %s\n", cmdline, params.replicaName)
+					if tc.workerJob == "" {
+						fmt.Fprint(params.stdout, "no newline")
+					}
+					return nil
+				},
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			err := cmd.runAllReplicas(t.Context(), apiClient, stdout, stderr)
+			if tc.expectedErr {
+				is.Error(err)
+				cliErr, ok := errors.AsType[*cli.Error](err)
+				is.True(ok)
+				is.Equal(tc.expectedCode, cliErr.ExitCode())
+			} else {
+				is.NoError(err)
+			}
+			for _, line := range tc.expectedStdout {
+				is.Contains(trimmedLines(stdout.String()), line, stdout.String())
+			}
+			for _, line := range tc.expectedStderr {
+				is.Contains(trimmedLines(stderr.String()), line, stderr.String())
+			}
+			is.Len(commands, len(tc.expectedReplicas))
+			for _, replica := range tc.expectedReplicas {
+				params, ok := commands[replica]
+				is.True(ok, replica)
+				is.Equal(append([]string{buildpackEntrypoint}, tc.command...), params.command)
+				is.False(params.enableStdin)
+				is.False(params.tty)
+			}
+		})
+	}
+}
+
+// trimmedLines returns the lines of s without trailing whitespace, which the
+// table writer adds to empty columns.
+func trimmedLines(s string) []string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return lines
+}
+
+func TestPrefixWriter(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	buf := &bytes.Buffer{}
+	w := &prefixWriter{mu: &sync.Mutex{}, out: buf, prefix: "a | "}
+	for _, s := range []string{"fir", "st\nsec", "ond\n", "\nlast"} {
+		_, err := w.Write([]byte(s))
+		is.NoError(err)
+	}
+	is.NoError(w.Flush())
+	is.Equal("a | first\na | second\na | \na | last\n", buf.String())
+}
@@ -0,0 +1,241 @@
+// Package export provides the implementation for the export command, which
+// writes the resources of a project to a directory of manifests.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/get"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+type Cmd struct {
+	format.Writer  `kong:"-"`
+	Directory      string   `arg:"" type:"path" help:"Directory to write the manifests to. It is created if it does not exist."`
+	Kinds          []string `help:"Only export resources of the given kinds."`
+	TargetProject  string   `help:"Rewrite the project of the exported resources, so they can be applied to a different project." placeholder:"project"`
+	IncludeSecrets bool     `help:"Also export Secrets referenced by the resources, like git auth and connection secrets. The secrets are written in clear text." default:"false"`
+	Force          bool     `help:"Write to the directory even if it is not empty, overwriting existing manifests." default:"false"`
+}
+
+// BeforeApply initializes Writer from Kong's bound [io.Writer].
+func (cmd *Cmd) BeforeApply(writer io.Writer) error {
+	return cmd.Writer.BeforeApply(writer)
+}
+
+// generatedKinds are created by Nine from other resources, e.g. a Release is
+// created for every Build of an Application. Recreating them elsewhere makes
+// no sense, so they are never exported.
+var generatedKinds = []string{apps.BuildKind, apps.ReleaseKind}
+
+// secretKind is the kind of exported secrets.
+const secretKind = "Secret"
+
+func (cmd *Cmd) Run(ctx context.Context, client *api.Client) error {
+	if err := cmd.checkDirectory(); err != nil {
+		return err
+	}
+
+	items, warnings, err := get.ProjectContent(ctx, client, []string{client.Project}, cmd.Kinds)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		cmd.Warningf("%s", w)
+	}
+	items = slices.DeleteFunc(items, generated)
+	if len(items) == 0 {
+		return cli.ErrorWithContext(fmt.Errorf("no resources found to export")).
+			WithExitCode(0).
+			WithContext("Project", client.Project)
+	}
+
+	if cmd.IncludeSecrets {
+		secrets, err := referencedSecrets(ctx, client, items)
+		if err != nil {
+			return err
+		}
+		items = append(items, secrets...)
+	}
+
+	target := cmd.TargetProject
+	if target == "" {
+		target = client.Project
+	}
+	for _, item := range items {
+		clean(item, client.Project, target)
+		if err := cmd.write(item); err != nil {
+			return err
+		}
+	}
+
+	if cmd.IncludeSecrets {
+		cmd.Warningf("the exported secrets contain credentials in clear text, make sure to store %s safely", cmd.Directory)
+	}
+	cmd.Successf("📦", "exported %d resources of project %s to %s", len(items), client.Project, cmd.Directory)
+	cmd.Printf("Recreate them with: %s apply -R -f %s\n", format.Command(), cmd.Directory)
+	return nil
+}
+
+// checkDirectory makes sure we do not mix the export with existing files,
+// unless forced.
+func (cmd *Cmd) checkDirectory() error {
+	entries, err := os.ReadDir(cmd.Directory)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(entries) == 0) || cmd.Force {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read directory %s: %w", cmd.Directory, err)
+	}
+	return cli.ErrorWithContext(fmt.Errorf("directory %s is not empty", cmd.Directory)).
+		WithExitCode(cli.ExitUsageError).
+		WithSuggestions("Use --force to overwrite existing manifests in the directory")
+}
+
+// generated returns true if the object is created by Nine or a controller
+// and should therefore not be exported.
+func generated(obj *unstructured.Unstructured) bool {
+	if slices.Contains(generatedKinds, obj.GetKind()) &&
+		obj.GroupVersionKind().Group == apps.Group {
+		return true
+	}
+	return metav1.GetControllerOfNoCopy(obj) != nil
+}
+
+// referencedSecrets returns the git auth and connection secrets which are
+// referenced by the objects. Secrets which do not exist are skipped.
+func referencedSecrets(ctx context.Context, client *api.Client, objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	seen := map[types.NamespacedName]bool{}
+	result := []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		for _, key := range secretReferences(obj) {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			secret := &unstructured.Unstructured{}
+			secret.SetAPIVersion(corev1.SchemeGroupVersion.String())
+			secret.SetKind(secretKind)
+			if err := client.Get(ctx, key, secret); err != nil {
+				if kerrors.IsNotFound(err) {
+					continue
+				}
+				return nil, fmt.Errorf("unable to get secret %s: %w", key.Name, err)
+			}
+			result = append(result, secret)
+		}
+	}
+	return result, nil
+}
+
+func secretReferences(obj *unstructured.Unstructured) []types.NamespacedName {
+	refs := []types.NamespacedName{}
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "writeConnectionSecretToRef", "name"); name != "" {
+		namespace, _, _ := unstructured.NestedString(obj.Object, "spec", "writeConnectionSecretToRef", "namespace")
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+		refs = append(refs, types.NamespacedName{Name: name, Namespace: namespace})
+	}
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "forProvider", "git", "auth", "fromSecret", "name"); name != "" {
+		refs = append(refs, types.NamespacedName{Name: name, Namespace: obj.GetNamespace()})
+	}
+	return refs
+}
+
+// clean removes all fields which are set by the API and rewrites references
+// to the source project to the target project.
+func clean(obj *unstructured.Unstructured, source, target string) {
+	for _, field := range [][]string{
+		{"status"},
+		{"metadata", "managedFields"},
+		{"metadata", "resourceVersion"},
+		{"metadata", "uid"},
+		{"metadata", "generation"},
+		{"metadata", "generateName"},
+		{"metadata", "creationTimestamp"},
+		{"metadata", "deletionTimestamp"},
+		{"metadata", "deletionGracePeriodSeconds"},
+		{"metadata", "ownerReferences"},
+		{"metadata", "finalizers"},
+		{"metadata", "selfLink"},
+		{"spec", "providerConfigRef"},
+		{"spec", "managementPolicies"},
+	} {
+		unstructured.RemoveNestedField(obj.Object, field...)
+	}
+
+	annotations := obj.GetAnnotations()
+	for k := range annotations {
+		if strings.HasPrefix(k, "crossplane.io") || strings.HasPrefix(k, "kubectl.kubernetes.io") {
+			delete(annotations, k)
+		}
+	}
+	obj.SetAnnotations(annotations)
+	if len(obj.GetAnnotations()) == 0 {
+		obj.SetAnnotations(nil)
+	}
+
+	obj.SetNamespace(target)
+	if spec, ok := obj.Object["spec"].(map[string]any); ok && source != target {
+		rewriteNamespace(spec, source, target)
+	}
+}
+
+// rewriteNamespace replaces all namespace fields pointing to the source
+// project, e.g. in references to other resources.
+func rewriteNamespace(value any, source, target string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if k == "namespace" && field == source {
+				v[k] = target
+				continue
+			}
+			rewriteNamespace(field, source, target)
+		}
+	case []any:
+		for _, item := range v {
+			rewriteNamespace(item, source, target)
+		}
+	}
+}
+
+// write writes the object to a file named after the object in a directory
+// named after its kind.
+func (cmd *Cmd) write(obj *unstructured.Unstructured) error {
+	dir := filepath.Join(cmd.Directory, strings.ToLower(obj.GetKind()))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("unable to create directory %s: %w", dir, err)
+	}
+	b, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	perm := os.FileMode(0o644)
+	if obj.GetKind() == secretKind {
+		perm = 0o600
+	}
+	path := filepath.Join(dir, obj.GetName()+".yaml")
+	if err := os.WriteFile(path, b, perm); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	return nil
+}
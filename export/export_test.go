@@ -0,0 +1,168 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	runtimev1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	meta "github.com/ninech/apis/meta/v1alpha1"
+	networking "github.com/ninech/apis/networking/v1alpha1"
+	storage "github.com/ninech/apis/storage/v1alpha1"
+	"github.com/ninech/nctl/apply"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	app := &apps.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Namespace:   test.DefaultProject,
+			Annotations: map[string]string{"crossplane.io/external-name": "app"},
+		},
+	}
+	app.Spec.ForProvider.Git.Auth = &apps.GitAuth{FromSecret: &meta.LocalReference{Name: "app-git-auth"}}
+	app.SetConditions(runtimev1.Available())
+
+	db := &storage.MySQL{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: test.DefaultProject}}
+	db.SetWriteConnectionSecretToReference(&runtimev1.SecretReference{Name: "db-conn", Namespace: test.DefaultProject})
+
+	sc := &networking.ServiceConnection{ObjectMeta: metav1.ObjectMeta{Name: "app-to-db", Namespace: test.DefaultProject}}
+	sc.Spec.ForProvider.Source.Reference = meta.TypedReference{
+		Reference: meta.Reference{Name: "app", Namespace: test.DefaultProject},
+		GroupKind: metav1.GroupKind{Group: apps.Group, Kind: apps.ApplicationKind},
+	}
+
+	objects := []client.Object{
+		app, db, sc,
+		&apps.Release{ObjectMeta: metav1.ObjectMeta{Name: "app-release", Namespace: test.DefaultProject}},
+		&storage.MySQLDatabase{ObjectMeta: metav1.ObjectMeta{
+			Name:      "owned",
+			Namespace: test.DefaultProject,
+			Labels:    map[string]string{meta.NineOwnedLabelKey: meta.NineOwnedLabelValue},
+		}},
+		&storage.PostgresDatabase{ObjectMeta: metav1.ObjectMeta{
+			Name:      "controlled",
+			Namespace: test.DefaultProject,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: storage.SchemeGroupVersion.String(), Kind: storage.PostgresKind,
+				Name: "pg", UID: "1234", Controller: ptr.To(true),
+			}},
+		}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-git-auth", Namespace: test.DefaultProject},
+			Data:       map[string][]byte{"password": []byte("secret")},
+		},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db-conn", Namespace: test.DefaultProject}},
+	}
+
+	tests := map[string]struct {
+		cmd          Cmd
+		existingFile bool
+		wantFiles    []string
+		wantMissing  []string
+		wantProject  string
+		wantErr      bool
+	}{
+		"export": {
+			wantFiles:   []string{"application/app.yaml", "mysql/db.yaml", "serviceconnection/app-to-db.yaml"},
+			wantMissing: []string{"release", "mysqldatabase", "postgresdatabase", "secret"},
+			wantProject: test.DefaultProject,
+		},
+		"target project and secrets": {
+			cmd:         Cmd{TargetProject: "target", IncludeSecrets: true},
+			wantFiles:   []string{"application/app.yaml", "secret/app-git-auth.yaml", "secret/db-conn.yaml"},
+			wantProject: "target",
+		},
+		"only kinds": {
+			cmd:         Cmd{Kinds: []string{"mysql"}},
+			wantFiles:   []string{"mysql/db.yaml"},
+			wantMissing: []string{"application", "serviceconnection"},
+			wantProject: test.DefaultProject,
+		},
+		"directory not empty": {
+			existingFile: true,
+			wantErr:      true,
+		},
+		"directory not empty forced": {
+			cmd:          Cmd{Force: true},
+			existingFile: true,
+			wantFiles:    []string{"application/app.yaml"},
+			wantProject:  test.DefaultProject,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t, test.WithObjects(objects...), test.WithKubeconfig())
+			dir := t.TempDir()
+			if tc.existingFile {
+				is.NoError(os.WriteFile(filepath.Join(dir, "README.md"), []byte("hi"), 0o644))
+			}
+
+			cmd := tc.cmd
+			cmd.Writer = format.NewWriter(&bytes.Buffer{})
+			cmd.Directory = dir
+			err := cmd.Run(t.Context(), apiClient)
+			if tc.wantErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+
+			for _, file := range tc.wantFiles {
+				is.FileExists(filepath.Join(dir, file))
+			}
+			for _, file := range tc.wantMissing {
+				is.NoFileExists(filepath.Join(dir, file))
+				is.NoDirExists(filepath.Join(dir, file))
+			}
+
+			f, err := os.Open(dir)
+			is.NoError(err)
+			objs, err := apply.ReadObjects(f, true, nil)
+			is.NoError(err)
+			is.NotEmpty(objs)
+			for _, obj := range objs {
+				is.Equal(tc.wantProject, obj.GetNamespace())
+				is.Empty(obj.GetResourceVersion())
+				is.Empty(obj.GetUID())
+				is.Nil(obj.GetManagedFields())
+				is.Nil(obj.GetOwnerReferences())
+				_, hasStatus := obj.Object["status"]
+				is.False(hasStatus, "%s %s has a status", obj.GetKind(), obj.GetName())
+
+				switch obj.GetKind() {
+				case apps.ApplicationKind:
+					is.Empty(obj.GetAnnotations())
+				case secretKind:
+					password, _, _ := unstructured.NestedString(obj.Object, "data", "password")
+					if obj.GetName() == "app-git-auth" {
+						is.NotEmpty(password)
+					}
+				}
+			}
+
+			if tc.wantProject != test.DefaultProject {
+				b, err := os.ReadFile(filepath.Join(dir, "serviceconnection", "app-to-db.yaml"))
+				is.NoError(err)
+				is.Contains(string(b), "namespace: "+tc.wantProject)
+				is.NotContains(string(b), "namespace: "+test.DefaultProject)
+			}
+		})
+	}
+}
@@ -3,25 +3,32 @@ package get
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 
-	infrastructure "github.com/ninech/apis/infrastructure/v1alpha1"
 	management "github.com/ninech/apis/management/v1alpha1"
-	meta "github.com/ninech/apis/meta/v1alpha1"
 	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/cli"
 	"github.com/ninech/nctl/internal/format"
+	"golang.org/x/sync/errgroup"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type allCmd struct {
 	Kinds                []string `help:"Specify the kind of resources which should be listed."`
 	IncludeNineResources bool     `help:"Show resources which are owned by Nine." default:"false"`
+	Stream               bool     `help:"Print resources as soon as they have been listed instead of sorted once all are listed. Only supported with the full and no-header output." default:"false"`
+
+	// onItems is called with the items of every completed list call.
+	onItems func([]*unstructured.Unstructured)
 }
 
 func (cmd *allCmd) Run(ctx context.Context, client *api.Client, get *Cmd) error {
@@ -34,7 +41,42 @@ func (cmd *allCmd) Run(ctx context.Context, client *api.Client, get *Cmd) error
 		return err
 	}
 
-	items, warnings, err := cmd.getProjectContent(ctx, client, projectNames(projectList))
+	labelSelector, fieldSelector, err := get.selectors()
+	if err != nil {
+		return err
+	}
+	var listOpts []runtimeclient.ListOption
+	if labelSelector != nil {
+		listOpts = append(listOpts, runtimeclient.MatchingLabelsSelector{Selector: labelSelector})
+	}
+	if fieldSelector != nil {
+		listOpts = append(listOpts, runtimeclient.MatchingFieldsSelector{Selector: fieldSelector})
+	}
+
+	var streamErr error
+	if cmd.Stream {
+		if get.Format != full && get.Format != noHeader {
+			return cli.ErrorWithContext(fmt.Errorf("--stream is not supported with output format %q", get.Format)).
+				WithExitCode(cli.ExitUsageError).
+				WithSuggestions("Use --output=full or --output=no-header")
+		}
+		listTypes, err := filteredListTypes(client.Scheme(), cmd.Kinds)
+		if err != nil {
+			return err
+		}
+		// the batches are flushed as they arrive, so the columns are
+		// set to fixed widths to keep the rows of all batches aligned.
+		get.tabWriter.SetRememberedWidths(streamWidths(projectNames(projectList), listTypes))
+		header := get.Format == full
+		cmd.onItems = func(items []*unstructured.Unstructured) {
+			if err := printItems(items, *get, header); err != nil && streamErr == nil {
+				streamErr = err
+			}
+			header = false
+		}
+	}
+
+	items, warnings, err := cmd.getProjectContent(ctx, client, projectNames(projectList), listOpts...)
 	if err != nil {
 		return err
 	}
@@ -46,12 +88,25 @@ func (cmd *allCmd) Run(ctx context.Context, client *api.Client, get *Cmd) error
 	if len(items) == 0 {
 		return get.notFound("Resource", projectName)
 	}
+	if cmd.Stream {
+		return streamErr
+	}
+
+	if get.Format.generic() {
+		list := &unstructured.UnstructuredList{}
+		for _, item := range items {
+			list.Items = append(list.Items, *item)
+		}
+		return get.printGeneric(client.Scheme(), list, false)
+	}
 
 	switch get.Format {
 	case full:
 		return printItems(items, *get, true)
 	case noHeader:
 		return printItems(items, *get, false)
+	case treeOut:
+		return printTree(items, get)
 	case yamlOut:
 		return format.PrettyPrintObjects(items, format.PrintOpts{Out: get.Writer})
 	case jsonOut:
@@ -73,46 +128,55 @@ func projectNames(projects []management.Project) []string {
 	return result
 }
 
+// ProjectContent returns all resources of the given kinds in the projects,
+// sorted by project, kind and name. All kinds are returned if no kinds are
+// given. Resources owned by Nine are left out. Errors when listing single
+// kinds are returned as warnings.
+func ProjectContent(
+	ctx context.Context,
+	client *api.Client,
+	projNames []string,
+	kinds []string,
+	listOpts ...runtimeclient.ListOption,
+) ([]*unstructured.Unstructured, []string, error) {
+	cmd := &allCmd{Kinds: kinds}
+	return cmd.getProjectContent(ctx, client, projNames, listOpts...)
+}
+
+// defaultListConcurrency is the amount of parallel list calls if the client
+// does not configure a burst.
+const defaultListConcurrency = 10
+
 func (cmd *allCmd) getProjectContent(
 	ctx context.Context,
 	client *api.Client,
 	projNames []string,
+	listOpts ...runtimeclient.ListOption,
 ) ([]*unstructured.Unstructured, []string, error) {
-	var warnings []string
-	var result []*unstructured.Unstructured
 	listTypes, err := filteredListTypes(client.Scheme(), cmd.Kinds)
 	if err != nil {
 		return nil, nil, err
 	}
+	c := &contentCollector{
+		includeNineResources: cmd.IncludeNineResources,
+		projects:             map[string]bool{},
+		onItems:              cmd.onItems,
+	}
 	for _, project := range projNames {
-		for _, listType := range listTypes {
-			u := &unstructured.UnstructuredList{}
-			u.SetGroupVersionKind(listType)
-			// if we get any errors during the listing of certain
-			// types we handle them as warnings to be able to
-			// return as many resources as we can
-			if err := client.List(ctx, u, runtimeclient.InNamespace(project)); err != nil {
-				if !kerrors.IsForbidden(err) {
-					warnings = append(warnings, err.Error())
-				}
-				continue
-			}
-			// we convert to a list of pointers so that we can
-			// directly call DeepCopyObject() on them and also
-			// filter nine owned resources if needed
-			for _, item := range u.Items {
-				if cmd.IncludeNineResources {
-					result = append(result, &item)
-					continue
-				}
-				if value, exists := item.GetLabels()[meta.NineOwnedLabelKey]; exists &&
-					value == meta.NineOwnedLabelValue {
-					continue
-				}
-				result = append(result, &item)
-			}
-		}
+		c.projects[project] = true
 	}
+
+	// When listing multiple projects, we first try to get every kind with a
+	// single list over all namespaces. This is usually only allowed for
+	// some users, so all kinds we could not list this way are then listed
+	// per project.
+	remaining := listTypes
+	if len(projNames) > 1 {
+		remaining = c.listAll(ctx, client, listTypes, listOpts)
+	}
+	c.listPerProject(ctx, client, remaining, projNames, listOpts)
+
+	result := c.items
 	// we sort the items of the project to always have the same stable
 	// output. We sort first by project, then by Kind and then by Name.
 	sort.Slice(
@@ -128,7 +192,170 @@ func (cmd *allCmd) getProjectContent(
 		},
 	)
 
-	return result, warnings, nil
+	return result, c.warnings, nil
+}
+
+// contentCollector collects the results of parallel list calls.
+type contentCollector struct {
+	includeNineResources bool
+	projects             map[string]bool
+	onItems              func([]*unstructured.Unstructured)
+
+	mu       sync.Mutex
+	items    []*unstructured.Unstructured
+	warnings []string
+}
+
+// listAll lists each of the list types across all namespaces and returns
+// the list types which could not be listed this way. The first list type is
+// listed on its own: if that is forbidden, the user is most likely not
+// allowed to list any kind across all namespaces and all list types are
+// returned without trying the others.
+func (c *contentCollector) listAll(
+	ctx context.Context,
+	client *api.Client,
+	listTypes []schema.GroupVersionKind,
+	listOpts []runtimeclient.ListOption,
+) []schema.GroupVersionKind {
+	if len(listTypes) == 0 {
+		return nil
+	}
+	failed := make([]bool, len(listTypes))
+	if err := c.listAllOf(ctx, client, listTypes[0], listOpts); err != nil {
+		if kerrors.IsForbidden(err) {
+			return listTypes
+		}
+		failed[0] = true
+	}
+
+	wg := errgroup.Group{}
+	wg.SetLimit(listConcurrency(client))
+	for i, listType := range listTypes[1:] {
+		wg.Go(func() error {
+			if err := c.listAllOf(ctx, client, listType, listOpts); err != nil {
+				failed[i+1] = true
+			}
+			return nil
+		})
+	}
+	_ = wg.Wait()
+
+	remaining := []schema.GroupVersionKind{}
+	for i, listType := range listTypes {
+		if failed[i] {
+			remaining = append(remaining, listType)
+		}
+	}
+	return remaining
+}
+
+// listAllOf lists the list type across all namespaces.
+func (c *contentCollector) listAllOf(
+	ctx context.Context,
+	client *api.Client,
+	listType schema.GroupVersionKind,
+	listOpts []runtimeclient.ListOption,
+) error {
+	u := &unstructured.UnstructuredList{}
+	u.SetGroupVersionKind(listType)
+	if err := client.List(ctx, u, listOpts...); err != nil {
+		return err
+	}
+	c.add(u.Items)
+	return nil
+}
+
+// listPerProject lists each of the list types in each of the projects.
+func (c *contentCollector) listPerProject(
+	ctx context.Context,
+	client *api.Client,
+	listTypes []schema.GroupVersionKind,
+	projNames []string,
+	listOpts []runtimeclient.ListOption,
+) {
+	wg := errgroup.Group{}
+	wg.SetLimit(listConcurrency(client))
+	for _, project := range projNames {
+		for _, listType := range listTypes {
+			wg.Go(func() error {
+				u := &unstructured.UnstructuredList{}
+				u.SetGroupVersionKind(listType)
+				// if we get any errors during the listing of certain
+				// types we handle them as warnings to be able to
+				// return as many resources as we can
+				if err := client.List(ctx, u, append(slices.Clone(listOpts), runtimeclient.InNamespace(project))...); err != nil {
+					if !kerrors.IsForbidden(err) {
+						c.warn(err.Error())
+					}
+					return nil
+				}
+				c.add(u.Items)
+				return nil
+			})
+		}
+	}
+	_ = wg.Wait()
+}
+
+// add adds the items of the requested projects, filtering nine owned
+// resources if needed.
+func (c *contentCollector) add(items []unstructured.Unstructured) {
+	// we convert to a list of pointers so that we can directly call
+	// DeepCopyObject() on them
+	added := []*unstructured.Unstructured{}
+	for _, item := range items {
+		if !c.projects[item.GetNamespace()] {
+			continue
+		}
+		if !c.includeNineResources && api.IsNineOwned(&item) {
+			continue
+		}
+		added = append(added, &item)
+	}
+	if len(added) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = append(c.items, added...)
+	if c.onItems != nil {
+		c.onItems(added)
+	}
+}
+
+func (c *contentCollector) warn(warning string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, warning)
+}
+
+// listConcurrency returns how many list calls are done in parallel. It is
+// bound by the burst of the client, as any more calls would just be
+// throttled by the client side rate limiter.
+func listConcurrency(client *api.Client) int {
+	if client.Config != nil && client.Config.Burst > 0 {
+		return client.Config.Burst
+	}
+	return defaultListConcurrency
+}
+
+// streamNameWidth is the width of the name column of streamed output. The
+// names are not known in advance, so it fits names up to the length of a DNS
+// label, which longer names exceed.
+const streamNameWidth = validation.DNS1123LabelMaxLength
+
+// streamWidths returns the widths of the project, name and kind columns of
+// streamed output, including the padding of the tab writer.
+func streamWidths(projects []string, listTypes []schema.GroupVersionKind) []int {
+	project, kind := len("PROJECT"), len("KIND")
+	for _, p := range projects {
+		project = max(project, len(p))
+	}
+	for _, listType := range listTypes {
+		kind = max(kind, len(strings.TrimSuffix(listType.Kind, "List")))
+	}
+	return []int{project + tabPadding, streamNameWidth + tabPadding, kind + tabPadding}
 }
 
 func printItems(items []*unstructured.Unstructured, get Cmd, header bool) error {
@@ -153,7 +380,7 @@ func printItems(items []*unstructured.Unstructured, get Cmd, header bool) error
 
 func filteredListTypes(s *runtime.Scheme, kinds []string) ([]schema.GroupVersionKind, error) {
 	result := []schema.GroupVersionKind{}
-	lists := nineListTypes(s)
+	lists := api.NineListTypes(s)
 	if len(kinds) == 0 {
 		return lists, nil
 	}
@@ -170,37 +397,3 @@ OUTER:
 	}
 	return result, nil
 }
-
-func excludeListType(gvk schema.GroupVersionKind) bool {
-	// ClusterData is a non-namespaced resource and used to allow
-	// connecting to deplo.io application replicas.
-	if strings.EqualFold(gvk.Kind, infrastructure.ClusterDataKind+"list") &&
-		strings.EqualFold(gvk.Group, infrastructure.Group) {
-		return true
-	}
-	return false
-}
-
-func nineListTypes(s *runtime.Scheme) []schema.GroupVersionKind {
-	var lists []schema.GroupVersionKind
-	for gvk := range s.AllKnownTypes() {
-		if !strings.HasSuffix(strings.ToLower(gvk.Kind), "list") {
-			continue
-		}
-		if excludeListType(gvk) {
-			continue
-		}
-		if strings.HasSuffix(strings.ToLower(gvk.Group), "nine.ch") {
-			lists = append(lists, gvk)
-		}
-	}
-	// we sort the items to have a predicatable order of types in the output
-	sort.Slice(
-		lists,
-		func(i, j int) bool {
-			return lists[i].Kind < lists[j].Kind
-		},
-	)
-
-	return lists
-}
@@ -2,9 +2,13 @@ package get
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"os"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	apps "github.com/ninech/apis/apps/v1alpha1"
 	infra "github.com/ninech/apis/infrastructure/v1alpha1"
@@ -13,9 +17,13 @@ import (
 	"github.com/ninech/nctl/api"
 	"github.com/ninech/nctl/internal/test"
 	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 func TestAllContent(t *testing.T) {
@@ -332,6 +340,147 @@ dev      pear    Release      apps.nine.ch
 	}
 }
 
+func TestAllContentListing(t *testing.T) {
+	t.Parallel()
+
+	objects := []client.Object{
+		testApplication("banana", "dev"), testRelease("pear", "dev"),
+		testApplication("apple", "staging"), testCluster("orange", "prod"),
+	}
+	const output = `PROJECT  NAME    KIND               GROUP
+dev      banana  Application        apps.nine.ch
+dev      pear    Release            apps.nine.ch
+prod     orange  KubernetesCluster  infrastructure.nine.ch
+staging  apple   Application        apps.nine.ch
+`
+
+	for name, tc := range map[string]struct {
+		clusterListForbidden bool
+		stream               bool
+		wantClusterLists     bool
+	}{
+		"cluster wide lists": {
+			wantClusterLists: true,
+		},
+		"per project lists": {
+			clusterListForbidden: true,
+		},
+		"stream": {
+			clusterListForbidden: true,
+			stream:               true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			const burst = 2
+			var inFlight, maxInFlight, clusterLists, projectLists atomic.Int32
+			apiClient := test.SetupClient(t,
+				test.WithProjects("dev", "staging", "prod"),
+				test.WithObjects(objects...),
+				test.WithNameIndexFor(&management.Project{}),
+				test.WithKubeconfig(),
+				test.WithInterceptorFuncs(interceptor.Funcs{
+					List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+						if _, ok := list.(*unstructured.UnstructuredList); !ok {
+							return c.List(ctx, list, opts...)
+						}
+						current := inFlight.Add(1)
+						defer inFlight.Add(-1)
+						for {
+							seen := maxInFlight.Load()
+							if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+								break
+							}
+						}
+						time.Sleep(time.Millisecond)
+
+						listOpts := &client.ListOptions{}
+						listOpts.ApplyOptions(opts)
+						if listOpts.Namespace == "" {
+							clusterLists.Add(1)
+							if tc.clusterListForbidden {
+								return kerrors.NewForbidden(schema.GroupResource{}, "", errors.New("forbidden"))
+							}
+						} else {
+							projectLists.Add(1)
+						}
+						return c.List(ctx, list, opts...)
+					},
+				}),
+			)
+			apiClient.Config.Burst = burst
+
+			buf := &bytes.Buffer{}
+			get := NewTestCmd(buf, full, WithAllProjects())
+			cmd := allCmd{Stream: tc.stream}
+			is.NoError(cmd.Run(t.Context(), apiClient, get))
+
+			listTypes := int32(len(api.NineListTypes(apiClient.Scheme())))
+			is.LessOrEqual(maxInFlight.Load(), int32(burst))
+			if tc.wantClusterLists {
+				is.Equal(listTypes, clusterLists.Load())
+				is.Zero(projectLists.Load())
+			} else {
+				is.Equal(int32(1), clusterLists.Load(), "no further cluster wide lists once forbidden")
+				is.Equal(listTypes*3, projectLists.Load(), "one list per kind and project")
+			}
+
+			if !tc.stream {
+				is.Equal(output, buf.String())
+				return
+			}
+			is.Equal(1, strings.Count(buf.String(), "PROJECT"))
+			for _, name := range []string{"banana", "pear", "orange", "apple"} {
+				is.Contains(buf.String(), name)
+			}
+		})
+	}
+}
+
+func TestAllContentStreamAligned(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	// every kind of every project is printed as a separate batch.
+	apiClient := test.SetupClient(t,
+		test.WithProjects("dev", "staging"),
+		test.WithObjects(
+			testApplication("a", "dev"),
+			testRelease("a-much-longer-release-name", "staging"),
+		),
+		test.WithNameIndexFor(&management.Project{}),
+		test.WithKubeconfig(),
+	)
+
+	buf := &bytes.Buffer{}
+	cmd := allCmd{Stream: true}
+	is.NoError(cmd.Run(t.Context(), apiClient, NewTestCmd(buf, full, WithAllProjects())))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	is.Len(lines, 3, buf.String())
+	kindColumn := strings.Index(lines[0], "KIND")
+	groupColumn := strings.Index(lines[0], "GROUP")
+	for _, line := range lines[1:] {
+		kind := strings.Index(line, apps.ApplicationKind)
+		if kind == -1 {
+			kind = strings.Index(line, apps.ReleaseKind)
+		}
+		is.Equal(kindColumn, kind, buf.String())
+		is.Equal(groupColumn, strings.Index(line, apps.Group), buf.String())
+	}
+}
+
+func TestAllContentStreamFormat(t *testing.T) {
+	t.Parallel()
+
+	apiClient := test.SetupClient(t, test.WithKubeconfig())
+	cmd := allCmd{Stream: true}
+	err := cmd.Run(t.Context(), apiClient, NewTestCmd(&bytes.Buffer{}, yamlOut))
+	require.ErrorContains(t, err, "--stream")
+}
+
 func testApplication(name, project string) *apps.Application {
 	return &apps.Application{
 		ObjectMeta: metav1.ObjectMeta{
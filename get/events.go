@@ -0,0 +1,129 @@
+package get
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type eventsCmd struct {
+	resourceCmd
+	For   string        `help:"Only get events of the resource in the form kind/name, e.g. application/myapp." placeholder:"kind/name"`
+	Types []string      `help:"Only get events of the given types, e.g. Warning." placeholder:"Normal,Warning"`
+	Since time.Duration `help:"Only get events which occurred within the given duration, e.g. 1h."`
+}
+
+const eventKind = "Event"
+
+func (cmd *eventsCmd) Run(ctx context.Context, client *api.Client, get *Cmd) error {
+	if _, _, err := cmd.involvedObject(); err != nil {
+		return err
+	}
+	return get.listPrint(ctx, client, cmd, api.MatchName(cmd.Name))
+}
+
+func (cmd *eventsCmd) list() client.ObjectList {
+	return &corev1.EventList{}
+}
+
+// involvedObject parses the --for flag into the kind and name of the object
+// the events should be shown for.
+func (cmd *eventsCmd) involvedObject() (string, string, error) {
+	if cmd.For == "" {
+		return "", "", nil
+	}
+	kind, name, ok := strings.Cut(cmd.For, "/")
+	if !ok || kind == "" || name == "" {
+		return "", "", cli.ErrorWithContext(fmt.Errorf("invalid value %q for --for, expected kind/name", cmd.For)).
+			WithExitCode(cli.ExitUsageError).
+			WithSuggestions(format.Command().Get("events", "--for", "application/myapp"))
+	}
+	return kind, name, nil
+}
+
+// filter removes all events not matching the flags of the command and sorts
+// the remaining events by the time they last occurred.
+func (cmd *eventsCmd) filter(list client.ObjectList) {
+	eventList, ok := list.(*corev1.EventList)
+	if !ok {
+		return
+	}
+	kind, name, _ := cmd.involvedObject()
+	eventList.Items = slices.DeleteFunc(eventList.Items, func(e corev1.Event) bool {
+		if kind != "" && (!strings.EqualFold(e.InvolvedObject.Kind, kind) || e.InvolvedObject.Name != name) {
+			return true
+		}
+		if len(cmd.Types) > 0 && !slices.ContainsFunc(cmd.Types, func(t string) bool {
+			return strings.EqualFold(t, e.Type)
+		}) {
+			return true
+		}
+		return cmd.Since != 0 && time.Since(api.EventTime(e)) > cmd.Since
+	})
+	sort.SliceStable(eventList.Items, func(i, j int) bool {
+		return api.EventTime(eventList.Items[i]).Before(api.EventTime(eventList.Items[j]))
+	})
+}
+
+func (cmd *eventsCmd) print(ctx context.Context, client *api.Client, list client.ObjectList, out *output) error {
+	eventList, ok := list.(*corev1.EventList)
+	if !ok {
+		return fmt.Errorf("expected %T, got %T", &corev1.EventList{}, list)
+	}
+	if len(eventList.Items) == 0 {
+		return out.notFound(eventKind, client.Project)
+	}
+
+	items := make([]*corev1.Event, 0, len(eventList.Items))
+	for i := range eventList.Items {
+		items = append(items, &eventList.Items[i])
+	}
+
+	switch out.Format {
+	case full:
+		return printEvents(eventList.Items, out, true)
+	case noHeader:
+		return printEvents(eventList.Items, out, false)
+	case yamlOut:
+		return format.PrettyPrintObjects(items, format.PrintOpts{Out: &out.Writer})
+	case jsonOut:
+		return format.PrettyPrintObjects(
+			items,
+			format.PrintOpts{
+				Out:    &out.Writer,
+				Format: format.OutputFormatTypeJSON,
+				JSONOpts: format.JSONOutputOptions{
+					PrintSingleItem: cmd.Name != "",
+				},
+			})
+	}
+
+	return nil
+}
+
+func printEvents(events []corev1.Event, out *output, header bool) error {
+	if header {
+		out.writeHeader("LAST SEEN", "TYPE", "REASON", "OBJECT", "MESSAGE")
+	}
+
+	for _, e := range events {
+		object := strings.ToLower(e.InvolvedObject.Kind) + "/" + e.InvolvedObject.Name
+		lastSeen := duration.HumanDuration(time.Since(api.EventTime(e)))
+		if e.Count > 1 {
+			lastSeen = fmt.Sprintf("%s (x%d)", lastSeen, e.Count)
+		}
+		out.writeTabRow(e.Namespace, lastSeen, e.Type, e.Reason, object, strings.TrimSpace(e.Message))
+	}
+
+	return out.tabWriter.Flush()
+}
@@ -0,0 +1,183 @@
+package get
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+
+	newEvent := func(name, project, kind, object, eventType string, age time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: project},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object},
+			Type:           eventType,
+			Reason:         name + "-reason",
+			Message:        name + "-message",
+			LastTimestamp:  metav1.NewTime(time.Now().Add(-age)),
+		}
+	}
+	events := []client.Object{
+		newEvent("app-failed", test.DefaultProject, "Application", "app", corev1.EventTypeWarning, time.Minute),
+		newEvent("app-created", test.DefaultProject, "Application", "app", corev1.EventTypeNormal, 2*time.Hour),
+		newEvent("db-created", test.DefaultProject, "MySQL", "db", corev1.EventTypeNormal, 10*time.Minute),
+		newEvent("other-created", "other", "MySQL", "db", corev1.EventTypeNormal, time.Minute),
+	}
+
+	tests := map[string]struct {
+		get           eventsCmd
+		out           outputFormat
+		inAllProjects bool
+		wantContain   []string
+		wantNot       []string
+		wantOrder     []string
+		wantLines     int
+		wantErr       bool
+		wantExitCode  int
+	}{
+		"all events ordered by time": {
+			wantContain: []string{"LAST SEEN", "app-failed-reason", "app-created-message", "application/app", "mysql/db"},
+			wantNot:     []string{"other-created"},
+			wantOrder:   []string{"app-created", "db-created", "app-failed"},
+			wantLines:   4,
+		},
+		"for object": {
+			get:         eventsCmd{For: "application/app"},
+			wantContain: []string{"app-failed", "app-created"},
+			wantNot:     []string{"db-created"},
+			wantLines:   3,
+		},
+		"types": {
+			get:         eventsCmd{Types: []string{"warning"}},
+			wantContain: []string{"app-failed", corev1.EventTypeWarning},
+			wantLines:   2,
+		},
+		"since": {
+			get:         eventsCmd{Since: time.Hour},
+			wantContain: []string{"app-failed", "db-created"},
+			wantNot:     []string{"app-created"},
+			wantLines:   3,
+		},
+		"all projects": {
+			get:           eventsCmd{Since: time.Hour, For: "MySQL/db"},
+			inAllProjects: true,
+			wantContain:   []string{"db-created", "other-created"},
+			wantLines:     3,
+		},
+		"name output": {
+			get:         eventsCmd{For: "mysql/db"},
+			out:         nameOut,
+			wantContain: []string{"event/db-created"},
+			wantNot:     []string{"app-"},
+			wantLines:   1,
+		},
+		"nothing found": {
+			get:     eventsCmd{For: "application/missing"},
+			wantErr: true,
+		},
+		"invalid for": {
+			get:          eventsCmd{For: "application"},
+			wantErr:      true,
+			wantExitCode: cli.ExitUsageError,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t,
+				test.WithProjectsFromResources(events...),
+				test.WithObjects(events...),
+				test.WithKubeconfig(),
+			)
+			if tc.out == "" {
+				tc.out = full
+			}
+			buf := &bytes.Buffer{}
+			cmd := NewTestCmd(buf, tc.out)
+			cmd.AllProjects = tc.inAllProjects
+
+			err := tc.get.Run(t.Context(), apiClient, cmd)
+			if tc.wantErr {
+				is.Error(err)
+				if tc.wantExitCode != 0 {
+					cliErr, ok := err.(*cli.Error)
+					is.True(ok)
+					is.Equal(tc.wantExitCode, cliErr.ExitCode())
+				}
+				return
+			}
+			is.NoError(err)
+
+			out := buf.String()
+			for _, s := range tc.wantContain {
+				is.Contains(out, s)
+			}
+			for _, s := range tc.wantNot {
+				is.NotContains(out, s)
+			}
+			last := -1
+			for _, s := range tc.wantOrder {
+				i := strings.Index(out, s)
+				is.Greater(i, last, "expected %q after the previous events", s)
+				last = i
+			}
+			is.Equal(tc.wantLines, test.CountLines(out), out)
+		})
+	}
+}
+
+func TestEventsWatch(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	newEvent := func(name, kind, object string) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: test.DefaultProject},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object},
+			Type:           corev1.EventTypeNormal,
+			Reason:         name + "-reason",
+			LastTimestamp:  metav1.Now(),
+		}
+	}
+	apiClient := test.SetupClient(t,
+		test.WithObjects(newEvent("app-created", "Application", "app")),
+		test.WithKubeconfig(),
+	)
+
+	buf := &bytes.Buffer{}
+	cmd := NewTestCmd(buf, full, WithWatch())
+	ctx, cancel := context.WithTimeout(t.Context(), 300*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		get := eventsCmd{For: "application/app"}
+		done <- get.Run(ctx, apiClient, cmd)
+	}()
+
+	// events of other objects are filtered out, which must not end the
+	// watch.
+	time.Sleep(30 * time.Millisecond)
+	is.NoError(apiClient.Create(ctx, newEvent("db-created", "MySQL", "db")))
+	time.Sleep(30 * time.Millisecond)
+	is.NoError(apiClient.Create(ctx, newEvent("app-updated", "Application", "app")))
+
+	is.NoError(<-done)
+	is.Contains(buf.String(), "app-created")
+	is.Contains(buf.String(), "app-updated")
+	is.NotContains(buf.String(), "db-created")
+}
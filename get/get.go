@@ -19,6 +19,9 @@ import (
 	"github.com/ninech/nctl/internal/cli"
 	"github.com/ninech/nctl/internal/format"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 )
@@ -27,8 +30,8 @@ type Cmd struct {
 	output
 	All                 allCmd                `cmd:"" name:"all" group:"get-general" help:"Get project content."`
 	Clusters            clustersCmd           `cmd:"" group:"get-infra" aliases:"cluster,vcluster" help:"Get Kubernetes Clusters."`
-	APIServiceAccounts  apiServiceAccountsCmd `cmd:"" group:"get-access" name:"apiserviceaccounts" aliases:"asa" help:"Get API Service Accounts."`
-	Projects            projectCmd            `cmd:"" group:"get-access" name:"projects" aliases:"proj" help:"Get Projects."`
+	APIServiceAccounts  apiServiceAccountsCmd `cmd:"" group:"get-access" name:"apiserviceaccounts" aliases:"asa,apiserviceaccount" help:"Get API Service Accounts."`
+	Projects            projectCmd            `cmd:"" group:"get-access" name:"projects" aliases:"proj,project" help:"Get Projects."`
 	Applications        applicationsCmd       `cmd:"" group:"get-apps" name:"applications" aliases:"app,apps,application" help:"Get deplo.io Applications."`
 	Builds              buildCmd              `cmd:"" group:"get-apps" name:"builds" aliases:"build" help:"Get deplo.io Builds."`
 	Releases            releasesCmd           `cmd:"" group:"get-apps" name:"releases" aliases:"release" help:"Get deplo.io Releases."`
@@ -45,14 +48,17 @@ type Cmd struct {
 	Bucket              bucketCmd             `cmd:"" group:"get-storage" name:"bucket" help:"Get Bucket instances."`
 	BucketUser          bucketUserCmd         `cmd:"" group:"get-storage" name:"bucketuser" aliases:"bu" help:"Get BucketUser instances."`
 	Grafana             grafanaCmd            `cmd:"" group:"get-observability" name:"grafana" help:"Get Grafana instances."`
+	Events              eventsCmd             `cmd:"" group:"get-general" name:"events" aliases:"event,ev" help:"Get Kubernetes Events of resources in the project."`
 }
 
 type output struct {
 	format.Writer `kong:"-"`
-	Format        outputFormat `help:"Configures list output. ${enum}" name:"output" short:"o" enum:"full,no-header,contexts,yaml,stats,json" default:"full"`
-	AllProjects   bool         `help:"apply the get over all projects." short:"A" xor:"watch"`
-	AllNamespaces bool         `help:"apply the get over all namespaces." hidden:"" xor:"watch"`
-	Watch         bool         `help:"Watch resource(s) for changes and print the updated resource." short:"w" xor:"watch"`
+	Format        outputFormat `help:"Configures list output. One of full, no-header, contexts, yaml, stats, json, name, tree (all only), custom-columns=<HEADER>:<json-path>[,...], jsonpath=<template> or go-template=<template>." name:"output" short:"o" default:"full"`
+	AllProjects   bool         `help:"apply the get over all projects." short:"A" xor:"all"`
+	AllNamespaces bool         `help:"apply the get over all namespaces." hidden:"" xor:"all"`
+	Watch         bool         `help:"Watch resource(s) for changes and print the updated resource." short:"w"`
+	Selector      string       `help:"Only get resources matching the label selector, e.g. -l 'team=web,env!=prod'." short:"l"`
+	FieldSelector string       `help:"Only get resources matching the field selector, e.g. --field-selector metadata.name=foo."`
 	tabWriter     *tabwriter.Writer
 }
 
@@ -61,6 +67,12 @@ type resourceCmd struct {
 	Name          string `arg:"" completion-predictor:"resource_name" help:"Name of the resource to get. If omitted all in the project will be listed." default:""`
 }
 
+// resourceName returns the name of the requested resource, which is empty
+// if all resources should be listed.
+func (cmd *resourceCmd) resourceName() string {
+	return cmd.Name
+}
+
 type outputFormat string
 
 const (
@@ -73,6 +85,9 @@ const (
 	noneText              = "<none>"
 )
 
+// tabPadding is the padding between the columns of the output.
+const tabPadding = 2
+
 // BeforeApply is called by Kong before parsing to initialize the output.
 func (cmd *Cmd) BeforeApply(writer io.Writer) error {
 	if err := cmd.output.BeforeApply(writer); err != nil {
@@ -80,7 +95,7 @@ func (cmd *Cmd) BeforeApply(writer io.Writer) error {
 	}
 
 	if cmd.tabWriter == nil {
-		cmd.tabWriter = tabwriter.NewWriter(writer, 0, 0, 2, ' ', tabwriter.RememberWidths)
+		cmd.tabWriter = tabwriter.NewWriter(writer, 0, 0, tabPadding, ' ', tabwriter.RememberWidths)
 	}
 
 	return nil
@@ -102,7 +117,26 @@ type listPrinter interface {
 	list() runtimeclient.ObjectList
 }
 
+// listFilter can be implemented by resources which filter the listed items
+// on the client side before they are printed.
+type listFilter interface {
+	filter(runtimeclient.ObjectList)
+}
+
 func (cmd *Cmd) listPrint(ctx context.Context, client *api.Client, lp listPrinter, opts ...api.ListOpt) error {
+	if cmd.Format == treeOut {
+		return treeOutUnsupported()
+	}
+	labelSelector, fieldSelector, err := cmd.selectors()
+	if err != nil {
+		return err
+	}
+	if labelSelector != nil {
+		opts = append(opts, api.MatchLabelSelector(labelSelector))
+	}
+	if fieldSelector != nil {
+		opts = append(opts, api.MatchFieldSelector(fieldSelector))
+	}
 	if cmd.AllProjects {
 		opts = append(opts, api.AllProjects())
 	}
@@ -111,7 +145,7 @@ func (cmd *Cmd) listPrint(ctx context.Context, client *api.Client, lp listPrinte
 	}
 	if cmd.Watch {
 		opts = append(opts, api.Watch(func(list runtimeclient.ObjectList) error {
-			return lp.print(ctx, client, list, &cmd.output)
+			return cmd.print(ctx, client, lp, list)
 		}))
 	}
 	list := lp.list()
@@ -121,7 +155,52 @@ func (cmd *Cmd) listPrint(ctx context.Context, client *api.Client, lp listPrinte
 	if cmd.Watch {
 		return nil
 	}
-	return lp.print(ctx, client, list, &cmd.output)
+	return cmd.print(ctx, client, lp, list)
+}
+
+// print prints the list with the printer of the resource, unless a generic
+// output format is requested which works the same for all resources.
+func (cmd *Cmd) print(ctx context.Context, client *api.Client, lp listPrinter, list runtimeclient.ObjectList) error {
+	if f, ok := lp.(listFilter); ok {
+		f.filter(list)
+		// while watching, changes which are filtered out are skipped
+		// instead of ending the watch as nothing was found.
+		if cmd.Watch && meta.LenList(list) == 0 {
+			return nil
+		}
+	}
+	if !cmd.Format.generic() || meta.LenList(list) == 0 {
+		return lp.print(ctx, client, list, &cmd.output)
+	}
+
+	single := false
+	if named, ok := lp.(interface{ resourceName() string }); ok {
+		single = named.resourceName() != ""
+	}
+	return cmd.printGeneric(client.Scheme(), list, single)
+}
+
+// selectors parses the label and field selector flags. The selectors are nil
+// if the flags are not set.
+func (out *output) selectors() (labels.Selector, fields.Selector, error) {
+	var labelSelector labels.Selector
+	var fieldSelector fields.Selector
+	if out.Selector != "" {
+		var err error
+		if labelSelector, err = labels.Parse(out.Selector); err != nil {
+			return nil, nil, cli.ErrorWithContext(fmt.Errorf("invalid label selector %q: %w", out.Selector, err)).
+				WithExitCode(cli.ExitUsageError)
+		}
+	}
+	if out.FieldSelector != "" {
+		var err error
+		if fieldSelector, err = fields.ParseSelector(out.FieldSelector); err != nil {
+			return nil, nil, cli.ErrorWithContext(fmt.Errorf("invalid field selector %q: %w", out.FieldSelector, err)).
+				WithExitCode(cli.ExitUsageError)
+		}
+	}
+
+	return labelSelector, fieldSelector, nil
 }
 
 // writeHeader writes the header row, prepending the always shown project
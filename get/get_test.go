@@ -10,6 +10,7 @@ import (
 	"time"
 
 	infrastructure "github.com/ninech/apis/infrastructure/v1alpha1"
+	"github.com/ninech/nctl/api"
 	"github.com/ninech/nctl/internal/format"
 	"github.com/ninech/nctl/internal/test"
 	"github.com/stretchr/testify/require"
@@ -69,23 +70,20 @@ func TestListPrint(t *testing.T) {
 			wantLines:   4,
 			watch:       true,
 		},
-		// TODO: watch currently does not support the all-projects or
-		// all-namespaces flags. This test should pass once that's implemented.
-		//
-		// "watch all projects": {
-		// 	out: full,
-		// 	existingResources: []client.Object{
-		// 		test.CloudVirtualMachine("foo", test.DefaultProject, "nine-es34", infrastructure.VirtualMachinePowerState("on")),
-		// 	},
-		// 	toCreate: []client.Object{
-		// 		test.CloudVirtualMachine("new", test.DefaultProject, "nine-es34", infrastructure.VirtualMachinePowerState("on")),
-		// 		test.CloudVirtualMachine("new2", "default-project", "nine-es34", infrastructure.VirtualMachinePowerState("on")),
-		// 	},
-		// 	wantContain:   []string{"foo", "new2"},
-		// 	wantLines:     4,
-		// 	inAllProjects: true,
-		// 	watch:         true,
-		// },
+		"watch all projects": {
+			out: full,
+			existingResources: []client.Object{
+				test.CloudVirtualMachine("foo", test.DefaultProject, "nine-es34", infrastructure.VirtualMachinePowerState("on")),
+			},
+			toCreate: []client.Object{
+				test.CloudVirtualMachine("new", test.DefaultProject, "nine-es34", infrastructure.VirtualMachinePowerState("on")),
+				test.CloudVirtualMachine("new2", "default-project", "nine-es34", infrastructure.VirtualMachinePowerState("on")),
+			},
+			wantContain:   []string{"foo", "new2"},
+			wantLines:     4,
+			inAllProjects: true,
+			watch:         true,
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -133,3 +131,129 @@ func TestListPrint(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectors(t *testing.T) {
+	t.Parallel()
+
+	vm := func(name, project string, labels map[string]string) client.Object {
+		obj := test.CloudVirtualMachine(name, project, "nine-es34", infrastructure.VirtualMachinePowerState("on"))
+		obj.Labels = labels
+		return obj
+	}
+	existing := []client.Object{
+		vm("web", test.DefaultProject, map[string]string{"team": "web"}),
+		vm("api", test.DefaultProject, map[string]string{"team": "api"}),
+		vm("unlabeled", test.DefaultProject, nil),
+		vm("other-web", "other", map[string]string{"team": "web"}),
+	}
+
+	tests := map[string]struct {
+		selector      string
+		fieldSelector string
+		name          string
+		allProjects   bool
+		wantNames     []string
+		wantErr       bool
+	}{
+		"label selector": {
+			selector:  "team=web",
+			wantNames: []string{"web"},
+		},
+		"set based label selector": {
+			selector:  "team in (web,api)",
+			wantNames: []string{"api", "web"},
+		},
+		"label selector in all projects": {
+			selector:    "team=web",
+			allProjects: true,
+			wantNames:   []string{"web", "other-web"},
+		},
+		"field selector": {
+			fieldSelector: "metadata.name=api",
+			wantNames:     []string{"api"},
+		},
+		"label selector and name": {
+			selector:  "team=web",
+			name:      "web",
+			wantNames: []string{"web"},
+		},
+		"invalid label selector": {
+			selector: "team in web",
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t,
+				test.WithProjectsFromResources(existing...),
+				test.WithObjects(existing...),
+				test.WithNameIndexFor(&infrastructure.CloudVirtualMachine{}),
+			)
+
+			buf := &bytes.Buffer{}
+			cmd := NewTestCmd(buf, nameOut)
+			cmd.Selector = tc.selector
+			cmd.FieldSelector = tc.fieldSelector
+			cmd.AllProjects = tc.allProjects
+
+			err := cmd.listPrint(t.Context(), apiClient, &cloudVMCmd{}, api.MatchName(tc.name))
+			if tc.wantErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+
+			var want strings.Builder
+			for _, name := range tc.wantNames {
+				want.WriteString("cloudvirtualmachine.infrastructure.nine.ch/" + name + "\n")
+			}
+			is.Equal(want.String(), buf.String())
+		})
+	}
+}
+
+func TestWatchAllProjectsFollowsProjects(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	vm := func(name, project string) client.Object {
+		return test.CloudVirtualMachine(name, project, "nine-es34", infrastructure.VirtualMachinePowerState("on"))
+	}
+	apiClient := test.SetupClient(t,
+		test.WithObjects(vm("existing", test.DefaultProject)),
+		test.WithKubeconfig(),
+	)
+
+	buf := &bytes.Buffer{}
+	cmd := NewTestCmd(buf, full, WithAllProjects(), WithWatch())
+	ctx, cancel := context.WithTimeout(t.Context(), 300*time.Millisecond)
+	defer cancel()
+
+	wg := sync.WaitGroup{}
+	wg.Go(func() {
+		if err := cmd.listPrint(ctx, apiClient, &cloudVMCmd{}); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+
+	// every step is delayed so that the watches of the projects are
+	// started or stopped before the next object is created.
+	step := func(f func() error) {
+		time.Sleep(30 * time.Millisecond)
+		is.NoError(f())
+	}
+	project := test.Projects(test.DefaultProject, "added")[0]
+	step(func() error { return apiClient.Create(ctx, project) })
+	step(func() error { return apiClient.Create(ctx, vm("in-added-project", "added")) })
+	step(func() error { return apiClient.Delete(ctx, project) })
+	step(func() error { return apiClient.Create(ctx, vm("in-removed-project", "added")) })
+	wg.Wait()
+
+	is.Contains(buf.String(), "existing")
+	is.Contains(buf.String(), "in-added-project")
+	is.NotContains(buf.String(), "in-removed-project")
+}
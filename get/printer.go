@@ -0,0 +1,272 @@
+package get
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/alecthomas/kong"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	nameOut          outputFormat = "name"
+	customColumnsOut outputFormat = "custom-columns"
+	jsonPathOut      outputFormat = "jsonpath"
+	goTemplateOut    outputFormat = "go-template"
+)
+
+// plainFormats are the output formats which don't take an argument.
+var plainFormats = []outputFormat{full, noHeader, contexts, yamlOut, stats, jsonOut, nameOut, treeOut}
+
+// argFormats are the output formats which take an argument in the form
+// format=argument.
+var argFormats = []outputFormat{customColumnsOut, jsonPathOut, goTemplateOut}
+
+// Decode implements [kong.MapperValue] to validate the output format and its
+// argument while parsing the flags.
+func (f *outputFormat) Decode(ctx *kong.DecodeContext) error {
+	var value string
+	if err := ctx.Scan.PopValueInto("output", &value); err != nil {
+		return err
+	}
+
+	parsed := outputFormat(value)
+	if err := parsed.validate(); err != nil {
+		return err
+	}
+	*f = parsed
+
+	return nil
+}
+
+func (f outputFormat) validate() error {
+	kind, arg := f.split()
+	switch {
+	case slices.Contains(plainFormats, outputFormat(f)):
+		return nil
+	case !slices.Contains(argFormats, kind):
+		return fmt.Errorf(
+			"invalid output format %q, must be one of %s or %s=<argument>",
+			f, joinFormats(plainFormats), joinFormats(argFormats),
+		)
+	case arg == "":
+		return fmt.Errorf("output format %s requires an argument, e.g. -o %s=<argument>", kind, kind)
+	}
+
+	var err error
+	switch kind {
+	case customColumnsOut:
+		_, err = parseCustomColumns(arg)
+	case jsonPathOut:
+		_, err = parseJSONPath(arg)
+	case goTemplateOut:
+		_, err = template.New("output").Parse(arg)
+	}
+
+	return err
+}
+
+// split returns the kind of the output format and its argument, e.g.
+// "jsonpath" and "{.metadata.name}" for "jsonpath={.metadata.name}".
+func (f outputFormat) split() (outputFormat, string) {
+	kind, arg, _ := strings.Cut(string(f), "=")
+	return outputFormat(kind), arg
+}
+
+// generic returns true if the output format is printed the same way for all
+// kinds of resources.
+func (f outputFormat) generic() bool {
+	kind, _ := f.split()
+	return kind == nameOut || slices.Contains(argFormats, kind)
+}
+
+func joinFormats(formats []outputFormat) string {
+	s := make([]string, 0, len(formats))
+	for _, f := range formats {
+		s = append(s, string(f))
+	}
+	return strings.Join(s, ", ")
+}
+
+// printGeneric prints the items of list in one of the generic output
+// formats. If single is set, the jsonpath and go-template formats are
+// applied to the only item instead of the whole list.
+func (out *output) printGeneric(scheme *runtime.Scheme, list runtime.Object, single bool) error {
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	objs := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		obj, err := toUnstructured(scheme, item)
+		if err != nil {
+			return err
+		}
+		objs = append(objs, obj)
+	}
+
+	kind, arg := out.Format.split()
+	switch kind {
+	case nameOut:
+		return out.printNames(objs)
+	case customColumnsOut:
+		return out.printCustomColumns(objs, arg)
+	}
+
+	var data any = map[string]any{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      objs,
+	}
+	if single && len(objs) == 1 {
+		data = objs[0]
+	}
+
+	switch kind {
+	case jsonPathOut:
+		jp, err := parseJSONPath(arg)
+		if err != nil {
+			return err
+		}
+		return jp.Execute(&out.Writer, data)
+	case goTemplateOut:
+		tmpl, err := template.New("output").Parse(arg)
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(&out.Writer, data)
+	}
+
+	return fmt.Errorf("unsupported output format %q", out.Format)
+}
+
+// toUnstructured converts obj to its unstructured representation. The
+// apiVersion and kind are set as typed objects usually don't have them.
+func toUnstructured(scheme *runtime.Scheme, obj runtime.Object) (map[string]any, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return nil, err
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u["apiVersion"] = gvk.GroupVersion().String()
+	u["kind"] = gvk.Kind
+
+	return u, nil
+}
+
+func (out *output) printNames(objs []map[string]any) error {
+	for _, obj := range objs {
+		apiVersion, _ := obj["apiVersion"].(string)
+		kind, _ := obj["kind"].(string)
+		metadata, _ := obj["metadata"].(map[string]any)
+		name, _ := metadata["name"].(string)
+
+		resource := strings.ToLower(kind)
+		if group, _, ok := strings.Cut(apiVersion, "/"); ok {
+			resource += "." + group
+		}
+		out.Printf("%s/%s\n", resource, name)
+	}
+
+	return nil
+}
+
+type customColumn struct {
+	header string
+	path   *jsonpath.JSONPath
+}
+
+// parseCustomColumns parses a spec like
+// "NAME:.metadata.name,SIZE:.spec.forProvider.size".
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	var columns []customColumn
+	for col := range strings.SplitSeq(spec, ",") {
+		header, expr, ok := strings.Cut(col, ":")
+		if !ok || header == "" || expr == "" {
+			return nil, fmt.Errorf("invalid custom column %q, expected <HEADER>:<json-path>", col)
+		}
+		path, err := parseJSONPath(expr)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, customColumn{header: header, path: path})
+	}
+
+	return columns, nil
+}
+
+func (out *output) printCustomColumns(objs []map[string]any, spec string) error {
+	columns, err := parseCustomColumns(spec)
+	if err != nil {
+		return err
+	}
+
+	// see writeHeader, the header is only printed once when watching.
+	if !out.Watch || len(out.tabWriter.RememberedWidths()) == 0 {
+		headers := make([]string, 0, len(columns))
+		for _, col := range columns {
+			headers = append(headers, col.header)
+		}
+		fmt.Fprintln(out.tabWriter, strings.Join(headers, "\t"))
+	}
+
+	for _, obj := range objs {
+		row := make([]string, 0, len(columns))
+		for _, col := range columns {
+			value, err := columnValue(col.path, obj)
+			if err != nil {
+				return err
+			}
+			row = append(row, value)
+		}
+		fmt.Fprintln(out.tabWriter, strings.Join(row, "\t"))
+	}
+
+	return out.tabWriter.Flush()
+}
+
+func columnValue(path *jsonpath.JSONPath, obj map[string]any) (string, error) {
+	results, err := path.FindResults(obj)
+	if err != nil {
+		return "", err
+	}
+
+	var values []string
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, fmt.Sprint(value.Interface()))
+		}
+	}
+	if len(values) == 0 {
+		return noneText, nil
+	}
+
+	return strings.Join(values, ","), nil
+}
+
+// parseJSONPath parses a JSONPath template. Like in kubectl, a plain
+// expression like ".metadata.name" is accepted without curly braces.
+func parseJSONPath(expr string) (*jsonpath.JSONPath, error) {
+	if !strings.Contains(expr, "{") {
+		if !strings.HasPrefix(expr, ".") {
+			expr = "." + expr
+		}
+		expr = "{" + expr + "}"
+	}
+
+	jp := jsonpath.New("output").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath %q: %w", expr, err)
+	}
+
+	return jp, nil
+}
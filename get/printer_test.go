@@ -0,0 +1,121 @@
+package get
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	runtimev1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	iam "github.com/ninech/apis/iam/v1alpha1"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGenericOutput(t *testing.T) {
+	t.Parallel()
+
+	asa := func(name string) client.Object {
+		return &iam.APIServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: test.DefaultProject},
+			Spec: iam.APIServiceAccountSpec{
+				ResourceSpec: runtimev1.ResourceSpec{
+					WriteConnectionSecretToReference: &runtimev1.SecretReference{
+						Name:      "asa-" + name,
+						Namespace: test.DefaultProject,
+					},
+				},
+			},
+		}
+	}
+	existing := []client.Object{asa("one"), asa("two")}
+
+	tests := map[string]struct {
+		format outputFormat
+		name   string
+		want   string
+	}{
+		"name": {
+			format: nameOut,
+			want:   "apiserviceaccount.iam.nine.ch/one\napiserviceaccount.iam.nine.ch/two\n",
+		},
+		"custom columns": {
+			format: "custom-columns=NAME:.metadata.name,SECRET:.spec.writeConnectionSecretToRef.name,MISSING:.status.nope",
+			want:   "NAME  SECRET   MISSING\none   asa-one  <none>\ntwo   asa-two  <none>\n",
+		},
+		"custom columns without leading dot": {
+			format: "custom-columns=NAME:metadata.name",
+			want:   "NAME\none\ntwo\n",
+		},
+		"jsonpath on list": {
+			format: "jsonpath={range .items[*]}{.metadata.name}={.spec.writeConnectionSecretToRef.name}{\"\\n\"}{end}",
+			want:   "one=asa-one\ntwo=asa-two\n",
+		},
+		"jsonpath on single item": {
+			format: "jsonpath={.spec.writeConnectionSecretToRef.name}",
+			name:   "two",
+			want:   "asa-two",
+		},
+		"go-template": {
+			format: "go-template={{range .items}}{{.kind}} {{.metadata.name}}\n{{end}}",
+			want:   "APIServiceAccount one\nAPIServiceAccount two\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			apiClient := test.SetupClient(t, test.WithObjects(existing...), test.WithNameIndexFor(&iam.APIServiceAccount{}))
+			buf := &bytes.Buffer{}
+			get := NewTestCmd(buf, tc.format)
+			cmd := apiServiceAccountsCmd{resourceCmd: resourceCmd{Name: tc.name}}
+
+			is.NoError(cmd.Run(t.Context(), apiClient, get))
+			is.Equal(tc.want, buf.String())
+		})
+	}
+}
+
+func TestOutputFormatDecode(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args    []string
+		want    outputFormat
+		wantErr bool
+	}{
+		"default":           {want: full},
+		"plain format":      {args: []string{"-o", "yaml"}, want: yamlOut},
+		"name":              {args: []string{"-o", "name"}, want: nameOut},
+		"jsonpath":          {args: []string{"-o", "jsonpath={.metadata.name}"}, want: "jsonpath={.metadata.name}"},
+		"unknown format":    {args: []string{"-o", "table"}, wantErr: true},
+		"missing argument":  {args: []string{"-o", "jsonpath"}, wantErr: true},
+		"invalid template":  {args: []string{"-o", "go-template={{.foo"}, wantErr: true},
+		"invalid column":    {args: []string{"-o", "custom-columns=NAME"}, wantErr: true},
+		"argument on plain": {args: []string{"-o", "yaml=foo"}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			out := &struct {
+				Format outputFormat `short:"o" name:"output" default:"full"`
+			}{}
+			parser, err := kong.New(out)
+			is.NoError(err)
+
+			_, err = parser.Parse(tc.args)
+			if tc.wantErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+			is.Equal(tc.want, out.Format)
+		})
+	}
+}
@@ -16,6 +16,9 @@ type projectCmd struct {
 }
 
 func (proj *projectCmd) Run(ctx context.Context, client *api.Client, get *Cmd) error {
+	if get.Format == treeOut {
+		return treeOutUnsupported()
+	}
 	projectList, err := client.Projects(ctx, proj.Name)
 	if err != nil {
 		return err
@@ -36,6 +39,10 @@ func (proj *projectCmd) Run(ctx context.Context, client *api.Client, get *Cmd) e
 		},
 	)
 
+	if get.Format.generic() {
+		return get.printGeneric(client.Scheme(), &management.ProjectList{Items: projectList}, proj.Name != "")
+	}
+
 	switch get.Format {
 	case full:
 		return printProject(projectList, *get, true)
@@ -0,0 +1,186 @@
+package get
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	networking "github.com/ninech/apis/networking/v1alpha1"
+	storage "github.com/ninech/apis/storage/v1alpha1"
+	"github.com/ninech/nctl/internal/application"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const treeOut outputFormat = "tree"
+
+// treeOutUnsupported returns a usage error for the sub-commands which
+// don't support the tree output, which is only printed by get all.
+func treeOutUnsupported() error {
+	return cli.ErrorWithContext(fmt.Errorf("output format %s is only supported by get all", treeOut)).
+		WithExitCode(cli.ExitUsageError).
+		WithSuggestions(format.Command().Get("all", "-o", string(treeOut)))
+}
+
+// treeNode is a resource or a property of a resource in the tree output.
+type treeNode struct {
+	label    string
+	children []*treeNode
+}
+
+// resourceKey identifies a resource within a project.
+type resourceKey struct {
+	kind string
+	name string
+}
+
+func keyOfItem(item *unstructured.Unstructured) resourceKey {
+	return resourceKey{kind: item.GetKind(), name: item.GetName()}
+}
+
+func (k resourceKey) String() string {
+	return k.kind + "/" + k.name
+}
+
+// printTree prints the items grouped by project, with related resources
+// nested below the resource they belong to. The items need to be sorted by
+// project.
+func printTree(items []*unstructured.Unstructured, get *Cmd) error {
+	for i := 0; i < len(items); {
+		project := items[i].GetNamespace()
+		end := i
+		for end < len(items) && items[end].GetNamespace() == project {
+			end++
+		}
+		roots, err := buildTree(items[i:end])
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			get.Println()
+		}
+		get.Printf("%s\n", project)
+		writeTreeNodes(get, roots, "")
+		i = end
+	}
+	return nil
+}
+
+func writeTreeNodes(get *Cmd, nodes []*treeNode, prefix string) {
+	for i, node := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+		get.Printf("%s%s%s\n", prefix, branch, node.label)
+		writeTreeNodes(get, node.children, prefix+indent)
+	}
+}
+
+// buildTree returns the root nodes of the items of a single project. Items
+// which belong to another item are nested below it instead of being a root.
+func buildTree(items []*unstructured.Unstructured) ([]*treeNode, error) {
+	nodes := make(map[resourceKey]*treeNode, len(items))
+	for _, item := range items {
+		nodes[keyOfItem(item)] = &treeNode{label: keyOfItem(item).String()}
+	}
+	nested := map[resourceKey]bool{}
+	nest := func(parent, child resourceKey) {
+		parentNode, ok := nodes[parent]
+		if !ok {
+			return
+		}
+		childNode, ok := nodes[child]
+		if !ok {
+			return
+		}
+		parentNode.children = append(parentNode.children, childNode)
+		nested[child] = true
+	}
+
+	for _, item := range items {
+		key := keyOfItem(item)
+		switch key.kind {
+		case apps.ReleaseKind, apps.BuildKind:
+			if app := item.GetLabels()[application.ApplicationNameLabel]; app != "" {
+				nest(resourceKey{kind: apps.ApplicationKind, name: app}, key)
+			}
+		case apps.ApplicationKind:
+			app := &apps.Application{}
+			if err := fromUnstructured(item, app); err != nil {
+				return nil, err
+			}
+			for _, svc := range app.Spec.ForProvider.Services {
+				nodes[key].children = append(nodes[key].children, &treeNode{
+					label: fmt.Sprintf("service %s → %s", svc.Name, resourceKey{kind: svc.Target.Kind, name: svc.Target.Name}),
+				})
+			}
+		case networking.StaticEgressKind:
+			egress := &networking.StaticEgress{}
+			if err := fromUnstructured(item, egress); err != nil {
+				return nil, err
+			}
+			target := egress.Spec.ForProvider.Target
+			nest(resourceKey{kind: target.Kind, name: target.Name}, key)
+		case storage.MySQLKind, storage.PostgresKind:
+			databases, _, _ := unstructured.NestedMap(item.Object, "status", "atProvider", "databases")
+			for _, name := range slices.Sorted(maps.Keys(databases)) {
+				nodes[key].children = append(nodes[key].children, &treeNode{label: "database " + name})
+			}
+		case storage.BucketKind:
+			bucket := &storage.Bucket{}
+			if err := fromUnstructured(item, bucket); err != nil {
+				return nil, err
+			}
+			for _, perm := range bucket.Spec.ForProvider.Permissions {
+				if perm == nil {
+					continue
+				}
+				for _, ref := range perm.BucketUserRefs {
+					if ref == nil || ref.Name == "" {
+						continue
+					}
+					// a user can be permitted on multiple buckets, so
+					// we add it with its role to each of them.
+					user := resourceKey{kind: storage.BucketUserKind, name: ref.Name}
+					nodes[key].children = append(nodes[key].children, &treeNode{
+						label: fmt.Sprintf("%s (%s)", user, perm.Role),
+					})
+					if _, exists := nodes[user]; exists {
+						nested[user] = true
+					}
+				}
+			}
+		case networking.ServiceConnectionKind:
+			sc := &networking.ServiceConnection{}
+			if err := fromUnstructured(item, sc); err != nil {
+				return nil, err
+			}
+			source := sc.Spec.ForProvider.Source.Reference
+			dest := sc.Spec.ForProvider.Destination
+			nodes[key].label += fmt.Sprintf(
+				": %s → %s",
+				resourceKey{kind: source.Kind, name: source.Name},
+				resourceKey{kind: dest.Kind, name: dest.Name},
+			)
+		}
+	}
+
+	roots := []*treeNode{}
+	for _, item := range items {
+		if !nested[keyOfItem(item)] {
+			roots = append(roots, nodes[keyOfItem(item)])
+		}
+	}
+	return roots, nil
+}
+
+func fromUnstructured(item *unstructured.Unstructured, obj any) error {
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, obj); err != nil {
+		return fmt.Errorf("unable to convert %s: %w", keyOfItem(item), err)
+	}
+	return nil
+}
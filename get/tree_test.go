@@ -0,0 +1,126 @@
+package get
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	meta "github.com/ninech/apis/meta/v1alpha1"
+	networking "github.com/ninech/apis/networking/v1alpha1"
+	storage "github.com/ninech/apis/storage/v1alpha1"
+	"github.com/ninech/nctl/internal/application"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPrintTree(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	appLabels := map[string]string{application.ApplicationNameLabel: "web"}
+	app := testApplication("web", "dev")
+	app.Spec.ForProvider.Services = apps.NamedServiceTargetList{{
+		Name: "cache",
+		Target: meta.TypedReference{
+			Reference: meta.Reference{Name: "kvs", Namespace: "dev"},
+			GroupKind: metav1.GroupKind{Group: storage.Group, Kind: storage.KeyValueStoreKind},
+		},
+	}}
+	release := testRelease("web-release", "dev")
+	release.Labels = appLabels
+	build := &apps.Build{ObjectMeta: metav1.ObjectMeta{Name: "web-build", Namespace: "dev", Labels: appLabels}}
+	egress := &networking.StaticEgress{ObjectMeta: metav1.ObjectMeta{Name: "web-egress", Namespace: "dev"}}
+	egress.Spec.ForProvider.Target = meta.LocalTypedReference{
+		LocalReference: meta.LocalReference{Name: "web"},
+		GroupKind:      metav1.GroupKind{Group: apps.Group, Kind: apps.ApplicationKind},
+	}
+	bucket := &storage.Bucket{ObjectMeta: metav1.ObjectMeta{Name: "files", Namespace: "dev"}}
+	bucket.Spec.ForProvider.Permissions = []*storage.BucketPermission{
+		{Role: storage.BucketRoleReader, BucketUserRefs: []*meta.LocalReference{{Name: "alice"}, {Name: "unknown"}}},
+	}
+	sc := &networking.ServiceConnection{ObjectMeta: metav1.ObjectMeta{Name: "web-to-db", Namespace: "prod"}}
+	sc.Spec.ForProvider.Source.Reference = meta.TypedReference{
+		Reference: meta.Reference{Name: "web", Namespace: "dev"},
+		GroupKind: metav1.GroupKind{Group: apps.Group, Kind: apps.ApplicationKind},
+	}
+	sc.Spec.ForProvider.Destination = meta.TypedReference{
+		Reference: meta.Reference{Name: "db", Namespace: "prod"},
+		GroupKind: metav1.GroupKind{Group: storage.Group, Kind: storage.MySQLKind},
+	}
+
+	db := toItem(t, &storage.MySQL{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod"}}, storage.MySQLKind)
+	is.NoError(unstructured.SetNestedMap(db.Object, map[string]any{"shop": map[string]any{}, "blog": map[string]any{}}, "status", "atProvider", "databases"))
+
+	// the items are sorted by project, kind and name like getProjectContent
+	// returns them.
+	items := []*unstructured.Unstructured{
+		toItem(t, app, apps.ApplicationKind),
+		toItem(t, bucket, storage.BucketKind),
+		toItem(t, &storage.BucketUser{ObjectMeta: metav1.ObjectMeta{Name: "alice", Namespace: "dev"}}, storage.BucketUserKind),
+		toItem(t, build, apps.BuildKind),
+		toItem(t, testRelease("orphan", "dev"), apps.ReleaseKind),
+		toItem(t, release, apps.ReleaseKind),
+		toItem(t, egress, networking.StaticEgressKind),
+		db,
+		toItem(t, sc, networking.ServiceConnectionKind),
+	}
+
+	buf := &bytes.Buffer{}
+	is.NoError(printTree(items, NewTestCmd(buf, treeOut)))
+	is.Equal(strings.ReplaceAll(`dev
+├── Application/web
+│   ├── service cache → KeyValueStore/kvs
+│   ├── Build/web-build
+│   ├── Release/web-release
+│   └── StaticEgress/web-egress
+├── Bucket/files
+│   ├── BucketUser/alice (ROLE)
+│   └── BucketUser/unknown (ROLE)
+└── Release/orphan
+
+prod
+├── MySQL/db
+│   ├── database blog
+│   └── database shop
+└── ServiceConnection/web-to-db: Application/web → MySQL/db
+`, "ROLE", string(storage.BucketRoleReader)), buf.String())
+}
+
+func toItem(t *testing.T, obj client.Object, kind string) *unstructured.Unstructured {
+	t.Helper()
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	item := &unstructured.Unstructured{Object: content}
+	item.SetKind(kind)
+	return item
+}
+
+func TestTreeOutUnsupported(t *testing.T) {
+	t.Parallel()
+
+	apiClient := test.SetupClient(t, test.WithKubeconfig())
+	for name, run := range map[string]func(*Cmd) error{
+		"applications": func(get *Cmd) error {
+			return (&applicationsCmd{}).Run(t.Context(), apiClient, get)
+		},
+		"projects": func(get *Cmd) error {
+			return (&projectCmd{}).Run(t.Context(), apiClient, get)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			err := run(NewTestCmd(&bytes.Buffer{}, treeOut))
+			cliErr, ok := err.(*cli.Error)
+			is.True(ok, "expected a cli error, got %v", err)
+			is.Equal(cli.ExitUsageError, cliErr.ExitCode())
+		})
+	}
+}
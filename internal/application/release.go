@@ -8,6 +8,9 @@ import (
 
 const (
 	ApplicationNameLabel = "application.apps.nine.ch/name"
+	// BuildpackEntrypoint is the launcher binary of buildpack images, which
+	// sets up the environment the application expects.
+	BuildpackEntrypoint = "/cnb/lifecycle/launcher"
 )
 
 // OrderReleaseList orders the given list of releases first by name and then by
@@ -0,0 +1,76 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Replica returns the name of a ready replica of the latest available release
+// of the app together with the release. If workerJob is set, a replica of
+// the worker job with that name is returned instead.
+func Replica(ctx context.Context, client *api.Client, app types.NamespacedName, workerJob string) (string, *apps.Release, error) {
+	replicas, release, err := Replicas(ctx, client, app, workerJob)
+	if err != nil {
+		return "", release, err
+	}
+	return replicas[0], release, nil
+}
+
+// Replicas returns the names of all ready replicas of the latest available
+// release of the app together with the release, like [Replica].
+func Replicas(ctx context.Context, client *api.Client, app types.NamespacedName, workerJob string) ([]string, *apps.Release, error) {
+	releases, err := Releases(ctx, client, app)
+	if err != nil {
+		return nil, nil, err
+	}
+	release := LatestAvailableRelease(releases)
+	if release == nil {
+		return nil, nil, fmt.Errorf("no ready release found for application %s", app.Name)
+	}
+	replicaObs := release.Status.AtProvider.ReplicaObservation
+
+	if workerJob != "" {
+		found := false
+		for _, wj := range release.Status.AtProvider.WorkerJobStatus {
+			if wj.Name == workerJob {
+				found = true
+				replicaObs = wj.ReplicaObservation
+			}
+		}
+		if !found {
+			availableJobs := make([]string, 0, len(release.Status.AtProvider.WorkerJobStatus))
+			for _, wj := range release.Status.AtProvider.WorkerJobStatus {
+				availableJobs = append(availableJobs, wj.Name)
+			}
+			return nil, release, cli.ErrorWithContext(fmt.Errorf("worker job %q not found", workerJob)).
+				WithAvailable(availableJobs...).
+				WithSuggestions(
+					fmt.Sprintf("List worker jobs: %s", format.Command().Get(apps.ApplicationKind, app.Name, "-o", "yaml")),
+				)
+		}
+	}
+
+	if len(replicaObs) == 0 {
+		return nil, release, fmt.Errorf("no replica information found for release %s", release.Name)
+	}
+	if replicas := readyReplicas(replicaObs); len(replicas) > 0 {
+		return replicas, release, nil
+	}
+	return nil, release, fmt.Errorf("no ready replica found for release %s", release.Name)
+}
+
+func readyReplicas(replicaObs []apps.ReplicaObservation) []string {
+	replicas := []string{}
+	for _, obs := range replicaObs {
+		if obs.Status == apps.ReplicaStatusReady {
+			replicas = append(replicas, obs.ReplicaName)
+		}
+	}
+	return replicas
+}
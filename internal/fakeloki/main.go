@@ -0,0 +1,63 @@
+// Command fakeloki runs a stand-in for the deplo.io logging API, serving the
+// log lines of fixture files. It allows to try the logs commands without the
+// real logging API by pointing nctl to it:
+//
+//	go run ./internal/fakeloki -listen localhost:3100 api/log/testdata
+//	nctl --log-api-address http://localhost:3100 logs project -p dev
+//
+// Lines written to stdin are added with the labels of the -labels flag, so
+// they show up when following logs.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ninech/nctl/api/log"
+)
+
+func main() {
+	listen := flag.String("listen", "localhost:3100", "address to listen on")
+	stdinLabels := flag.String("labels", "namespace=default,app=stdin", "labels of the lines read from stdin, as comma separated key=value pairs")
+	flag.Parse()
+
+	if err := run(*listen, *stdinLabels, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(listen, stdinLabels string, fixtures []string) error {
+	labels := map[string]string{}
+	for pair := range strings.SplitSeq(stdinLabels, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid label %q, expected key=value", pair)
+		}
+		labels[key] = value
+	}
+
+	streams, err := log.LoadFixtures(fixtures...)
+	if err != nil {
+		return err
+	}
+	server := log.NewServer(streams...)
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			server.Push(log.Stream{
+				Labels:  labels,
+				Entries: []log.Entry{{Timestamp: time.Now(), Line: scanner.Text()}},
+			})
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "serving %d streams on http://%s\n", len(streams), listen)
+	return http.ListenAndServe(listen, server)
+}
@@ -146,6 +146,14 @@ func SetupClient(t *testing.T, opts ...ClientSetupOption) *api.Client {
 	resources = append(resources, setup.objects...)
 
 	clientBuilder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(resources...)
+	// the API server allows selecting events by the object they involve.
+	clientBuilder = clientBuilder.
+		WithIndex(&corev1.Event{}, api.EventObjectNameField, func(o client.Object) []string {
+			return []string{o.(*corev1.Event).InvolvedObject.Name}
+		}).
+		WithIndex(&corev1.Event{}, api.EventObjectKindField, func(o client.Object) []string {
+			return []string{o.(*corev1.Event).InvolvedObject.Kind}
+		})
 	for _, res := range setup.nameIndexesOn {
 		clientBuilder = clientBuilder.WithIndex(res, "metadata.name", func(o client.Object) []string {
 			return []string{o.GetName()}
@@ -11,23 +11,55 @@ import (
 type applicationCmd struct {
 	resourceCmd
 	logsCmd
-	Type appLogType `short:"t" help:"Which type of app logs to output. ${enum}" enum:"all,app,build,worker_job,deploy_job,scheduled_job" default:"all"`
+	Type         appLogType `short:"t" help:"Which type of app logs to output. ${enum}" enum:"all,app,build,worker_job,deploy_job,scheduled_job" default:"all"`
+	Replica      string     `help:"Only output logs of the replica with the given name."`
+	WorkerJob    string     `help:"Only output logs of the worker job with the given name."`
+	DeployJob    string     `help:"Only output logs of the deploy job with the given name."`
+	ScheduledJob string     `help:"Only output logs of the scheduled job with the given name."`
 }
 
 func (cmd *applicationCmd) Run(ctx context.Context, client *api.Client) error {
-	if cmd.Name == "" {
+	if cmd.Name == "" && cmd.Query == "" {
 		return errors.New("please specify an application name")
 	}
-	if err := client.GetObject(ctx, cmd.Name, &apps.Application{}); err != nil {
+	if err := cmd.checkQuery(cmd.Name, cmd.query(client.Project)); err != nil {
 		return err
 	}
+	if cmd.Name != "" {
+		if err := client.GetObject(ctx, cmd.Name, &apps.Application{}); err != nil {
+			return err
+		}
+	}
+
+	return cmd.logsCmd.Run(ctx, client, cmd.query(client.Project),
+		applicationLabels...,
+	)
+}
 
-	return cmd.logsCmd.Run(ctx, client, buildQuery(append(
+// applicationLabels are the labels shown in the logs of applications.
+var applicationLabels = []string{
+	apps.LogLabelBuild, apps.LogLabelReplica, apps.LogLabelWorkerJob, apps.LogLabelDeployJob, apps.LogLabelScheduledJob,
+}
+
+// query returns the label selector for the logs of the application, limited
+// to the type and the replica or job names set on the command.
+func (cmd *applicationCmd) query(project string) string {
+	expr := append(
 		cmd.Type.queryExpressions(),
-		inProject(client.Project),
-		queryExpr(opEquals, apps.LogLabelApplication, cmd.Name))...),
-		apps.LogLabelBuild, apps.LogLabelReplica, apps.LogLabelWorkerJob, apps.LogLabelDeployJob, apps.LogLabelDeployJob,
+		inProject(project),
+		queryExpr(opEquals, apps.LogLabelApplication, cmd.Name),
 	)
+	for _, filter := range []struct{ label, name string }{
+		{label: apps.LogLabelReplica, name: cmd.Replica},
+		{label: apps.LogLabelWorkerJob, name: cmd.WorkerJob},
+		{label: apps.LogLabelDeployJob, name: cmd.DeployJob},
+		{label: apps.LogLabelScheduledJob, name: cmd.ScheduledJob},
+	} {
+		if filter.name != "" {
+			expr = append(expr, queryExpr(opEquals, filter.label, filter.name))
+		}
+	}
+	return buildQuery(expr...)
 }
 
 func ApplicationQuery(name, project string) string {
@@ -17,9 +17,15 @@ type buildCmd struct {
 }
 
 func (cmd *buildCmd) Run(ctx context.Context, client *api.Client) error {
-	if cmd.Name == "" && cmd.ApplicationName == "" {
+	if cmd.Name == "" && cmd.ApplicationName == "" && cmd.Query == "" {
 		return errors.New("please specify a build name or an application name to see build logs from")
 	}
+	if err := cmd.checkQuery(cmd.Name, BuildQuery(cmd.Name, client.Project)); err != nil {
+		return err
+	}
+	if err := cmd.checkQuery(cmd.ApplicationName, BuildsOfAppQuery(cmd.ApplicationName, client.Project)); err != nil {
+		return err
+	}
 	if cmd.Name != "" {
 		build := &apps.Build{}
 		if err := client.GetObject(ctx, cmd.Name, build); err != nil {
@@ -3,21 +3,29 @@ package logs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/ninech/nctl/api"
 	"github.com/ninech/nctl/api/log"
+	"github.com/ninech/nctl/internal/cli"
 	"github.com/ninech/nctl/internal/format"
+	"github.com/prometheus/common/model"
 )
 
 type Cmd struct {
 	Applications applicationCmd `cmd:"" group:"deplo.io" name:"application" aliases:"app,application" help:"Get deplo.io Application logs."`
 	Builds       buildCmd       `cmd:"" group:"deplo.io" name:"build" help:"Get deplo.io Build logs."`
+	Project      projectCmd     `cmd:"" group:"deplo.io" name:"project" aliases:"proj" help:"Get the logs of all deplo.io Applications in the project."`
 }
 
 type resourceCmd struct {
@@ -27,13 +35,23 @@ type resourceCmd struct {
 type logsCmd struct {
 	format.Writer `kong:"-"`
 	Follow        bool          `help:"Follow the logs by live tailing." short:"f"`
-	Lines         int           `help:"Amount of lines to output." default:"50" short:"l"`
+	Lines         int           `help:"Amount of lines to output. 0 outputs all lines between --from and --to." default:"50" short:"l" aliases:"limit"`
+	All           bool          `help:"Output all lines between --from and --to by fetching them in batches. Same as --lines 0."`
+	OutputFile    string        `help:"Write the logs to the given file instead of stdout." type:"path" placeholder:"file"`
 	Since         time.Duration `help:"Duration how long to look back for logs." short:"s" default:"${log_retention}"`
 	From          time.Time     `help:"Ignore since flag and start looking for logs at this absolute time (RFC3339)." placeholder:"2025-01-01T14:00:00+01:00"`
 	To            time.Time     `help:"Ignore since flag and stop looking for logs at this absolute time (RFC3339)." placeholder:"2025-01-01T15:00:00+01:00"`
-	Output        string        `help:"Configures the log output format. ${enum}" short:"o" enum:"default,json" default:"default"`
+	Output        string        `help:"Configures the log output format. sparkline and histogram are only supported for metric queries. ${enum}" short:"o" enum:"default,json,sparkline,histogram" default:"default"`
 	NoLabels      bool          `help:"Disable labels in log output."`
+	Grep          []string      `help:"Only output lines containing the given text. Can be repeated, all of them need to match." placeholder:"text"`
+	GrepV         []string      `help:"Only output lines not containing the given text. Can be repeated." placeholder:"text"`
+	Regex         bool          `help:"Interpret the values of --grep and --grep-v as regular expressions (RE2 syntax)." short:"E"`
+	JSONField     []string      `name:"json-field" help:"Parse JSON log lines and only output lines where the field has the given value. Nested fields are joined with dots, e.g. request.method=GET." placeholder:"key=value"`
+	CountBy       time.Duration `help:"Output the amount of log lines per interval instead of the lines, e.g. 5m." placeholder:"5m"`
+	Query         string        `help:"Run the given LogQL query instead of the query of the resource. Metric queries like rate({app=\"myapp\"} |= \"error\" [5m]) are supported. Can not be combined with the name of a resource." placeholder:"logql"`
 	out           log.Output
+	// prefixLabel is the label whose value is prefixed to every line.
+	prefixLabel string
 }
 
 // BeforeApply initializes Writer from Kong's bound [io.Writer].
@@ -66,6 +84,14 @@ func (cmd *logsCmd) Run(
 		)
 	}
 
+	queryString, metric, err := cmd.queryString(queryString)
+	if err != nil {
+		return err
+	}
+	if err := cmd.validateOutput(metric); err != nil {
+		return err
+	}
+
 	query := log.Query{
 		QueryString: queryString,
 		Limit:       cmd.Lines,
@@ -75,7 +101,34 @@ func (cmd *logsCmd) Run(
 		Quiet:       true,
 	}
 
-	out, err := log.NewOutput(cmd.Writer, log.Mode(cmd.Output), cmd.NoLabels, labels...)
+	var w io.Writer = cmd.Writer
+	newOutput := log.NewOutput
+	if cmd.OutputFile != "" {
+		f, err := os.Create(cmd.OutputFile)
+		if err != nil {
+			return fmt.Errorf("unable to create output file: %w", err)
+		}
+		defer f.Close()
+		w, newOutput = f, log.NewPlainOutput
+	}
+
+	if metric {
+		query.Step = cmd.CountBy
+		series, err := client.Log.QueryMetrics(ctx, query)
+		if err != nil {
+			return err
+		}
+		if len(series) == 0 {
+			return fmt.Errorf(
+				"no logs found between %s and %s",
+				start.Format(time.RFC3339),
+				end.Format(time.RFC3339),
+			)
+		}
+		return log.PrintSeries(w, series, log.MetricMode(cmd.Output), query.Step)
+	}
+
+	out, err := newOutput(w, log.Mode(cmd.Output), cmd.NoLabels, labels...)
 	if err != nil {
 		return err
 	}
@@ -83,18 +136,22 @@ func (cmd *logsCmd) Run(
 	if cmd.out != nil {
 		out = cmd.out
 	}
+	if cmd.prefixLabel != "" && cmd.Output != "json" {
+		out = log.NewPrefixedOutput(out, cmd.prefixLabel, cmd.OutputFile == "")
+	}
 
+	if err := cmd.queryRange(ctx, client, out, w, query); err != nil {
+		return err
+	}
 	if cmd.Follow {
-		if err := client.Log.QueryRange(ctx, out, query); err != nil {
-			return err
-		}
 		query.Start = end
+		if query.Limit <= 0 {
+			// tailing requires a limit, which is the amount of lines
+			// sent per message.
+			query.Limit = log.DefaultBatchSize
+		}
 		return client.Log.TailQuery(ctx, 0, out, query)
 	}
-
-	if err := client.Log.QueryRange(ctx, out, query); err != nil {
-		return err
-	}
 	if out.LineCount() == 0 {
 		return fmt.Errorf(
 			"no logs found between %s and %s",
@@ -106,6 +163,163 @@ func (cmd *logsCmd) Run(
 	return nil
 }
 
+// queryRange prints the logs of the query. If all lines are requested, they
+// are fetched in batches and the progress is shown on stderr, unless the logs
+// are printed to the terminal as well.
+func (cmd *logsCmd) queryRange(ctx context.Context, client *api.Client, out log.Output, w io.Writer, query log.Query) error {
+	if !cmd.All && cmd.Lines != 0 {
+		return client.Log.QueryRange(ctx, out, query)
+	}
+
+	// warnings are written to stderr to not end up within the logs.
+	stderr := format.NewWriter(os.Stderr)
+	warn := func(msg string) { stderr.Warningf("%s", msg) }
+	var progress func(total int, last time.Time)
+	if !format.IsInteractiveEnvironment(w) && format.IsInteractiveEnvironment(os.Stderr) {
+		spinner, err := stderr.Spinner(
+			format.Progress("📜", "fetching logs"),
+			format.Progress("📜", "fetched logs"),
+		)
+		if err != nil {
+			return err
+		}
+		_ = spinner.Start()
+		defer func() { _ = spinner.Stop() }()
+		progress = func(total int, last time.Time) {
+			spinner.Message(format.Progressf("📜", "fetched %d lines up to %s", total, last.Local().Format(time.RFC3339)))
+		}
+	}
+
+	return client.Log.QueryRangeAll(ctx, out, query, log.DefaultBatchSize, progress, warn)
+}
+
+// queryString returns the query to run, which is either the log query of the
+// resource with the pipeline of the command or the query set with --query.
+// The query is turned into a metric query if --count-by is set.
+func (cmd *logsCmd) queryString(resourceQuery string) (string, bool, error) {
+	pipeline, err := cmd.pipeline()
+	if err != nil {
+		return "", false, err
+	}
+
+	logQuery := resourceQuery + pipeline
+	if cmd.Query != "" {
+		expr, err := syntax.ParseExpr(cmd.Query)
+		if err != nil {
+			return "", false, cli.ErrorWithContext(fmt.Errorf("invalid query %q: %w", cmd.Query, err)).
+				WithExitCode(cli.ExitUsageError)
+		}
+		if _, ok := expr.(syntax.SampleExpr); ok {
+			if pipeline != "" || cmd.CountBy != 0 {
+				return "", false, cli.ErrorWithContext(errors.New("line filters and --count-by can only be used with log queries")).
+					WithExitCode(cli.ExitUsageError)
+			}
+			return cmd.Query, true, nil
+		}
+		logQuery = cmd.Query + pipeline
+	}
+
+	if cmd.CountBy != 0 {
+		return fmt.Sprintf("sum(count_over_time(%s [%s]))", logQuery, model.Duration(cmd.CountBy)), true, nil
+	}
+	return logQuery, false, nil
+}
+
+// checkQuery returns a usage error if --query is set together with the name
+// of a resource, as the raw query replaces the query of the resource instead
+// of being limited to it. resourceQuery is suggested as a starting point.
+func (cmd *logsCmd) checkQuery(name, resourceQuery string) error {
+	if cmd.Query == "" || name == "" {
+		return nil
+	}
+	return cli.ErrorWithContext(fmt.Errorf("--query can not be used together with the name %q", name)).
+		WithExitCode(cli.ExitUsageError).
+		WithSuggestions(fmt.Sprintf("Select the logs of %s within the query instead, e.g. --query '%s'", name, resourceQuery))
+}
+
+// validateOutput checks that the flags of the command are supported for the
+// kind of query.
+func (cmd *logsCmd) validateOutput(metric bool) error {
+	graph := cmd.Output == string(log.MetricSparkline) || cmd.Output == string(log.MetricHistogram)
+	switch {
+	case metric && cmd.Follow:
+		return cli.ErrorWithContext(errors.New("metric queries can not be followed")).
+			WithExitCode(cli.ExitUsageError)
+	case !metric && graph:
+		return cli.ErrorWithContext(fmt.Errorf("output %s is only supported for metric queries", cmd.Output)).
+			WithExitCode(cli.ExitUsageError).
+			WithSuggestions(fmt.Sprintf("%s logs app myapp --count-by 5m -o %s", format.Command(), cmd.Output))
+	}
+	return nil
+}
+
+// pipeline returns the LogQL pipeline stages for the line filters and JSON
+// fields of the command. It is empty if no filters are set.
+func (cmd *logsCmd) pipeline() (string, error) {
+	include, exclude := lineFilterContains, lineFilterNotContains
+	if cmd.Regex {
+		include, exclude = lineFilterMatches, lineFilterNotMatches
+	}
+
+	stages := []string{}
+	for _, filters := range []struct {
+		op     lineFilterOperator
+		flag   string
+		values []string
+	}{
+		{op: include, flag: "--grep", values: cmd.Grep},
+		{op: exclude, flag: "--grep-v", values: cmd.GrepV},
+	} {
+		for _, value := range filters.values {
+			if cmd.Regex {
+				if _, err := regexp.Compile(value); err != nil {
+					return "", cli.ErrorWithContext(fmt.Errorf("invalid regular expression %q for %s: %w", value, filters.flag, err)).
+						WithExitCode(cli.ExitUsageError)
+				}
+			}
+			stages = append(stages, lineFilter(filters.op, value))
+		}
+	}
+
+	if len(cmd.JSONField) == 0 {
+		return strings.Join(stages, ""), nil
+	}
+	stages = append(stages, " | json")
+	for _, field := range cmd.JSONField {
+		key, value, ok := strings.Cut(field, "=")
+		// the json parser joins nested fields with an underscore
+		key = strings.ReplaceAll(key, ".", "_")
+		if !ok || !labelNamePattern.MatchString(key) {
+			return "", cli.ErrorWithContext(fmt.Errorf("invalid value %q for --json-field, expected key=value", field)).
+				WithExitCode(cli.ExitUsageError).
+				WithSuggestions(fmt.Sprintf("%s logs app myapp --json-field level=error", format.Command()))
+		}
+		stages = append(stages, " | "+labelFilter(key, value))
+	}
+	return strings.Join(stages, ""), nil
+}
+
+type lineFilterOperator string
+
+const (
+	lineFilterContains    lineFilterOperator = "|="
+	lineFilterNotContains lineFilterOperator = "!="
+	lineFilterMatches     lineFilterOperator = "|~"
+	lineFilterNotMatches  lineFilterOperator = "!~"
+)
+
+// labelNamePattern matches valid label names, which are also used for the
+// fields extracted by the json parser.
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func lineFilter(operator lineFilterOperator, value string) string {
+	return fmt.Sprintf(" %s %s", operator, strconv.Quote(value))
+}
+
+func labelFilter(key, value string) string {
+	return fmt.Sprintf("%s=%s", key, strconv.Quote(value))
+}
+
 type queryOperator string
 
 const (
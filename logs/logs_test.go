@@ -4,13 +4,19 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	apps "github.com/ninech/apis/apps/v1alpha1"
 	"github.com/ninech/nctl/api"
 	"github.com/ninech/nctl/api/log"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
 	"github.com/stretchr/testify/require"
 )
 
@@ -82,6 +88,39 @@ func TestRun(t *testing.T) {
 			},
 			expectedLines: len(lines),
 		},
+		"all lines": {
+			cmd: logsCmd{
+				Output: "default",
+				All:    true,
+			},
+			expectedLines: len(lines),
+		},
+		"limit 0": {
+			cmd: logsCmd{
+				Output: "default",
+				Lines:  0,
+			},
+			expectedLines: len(lines),
+		},
+		"follow with line filters": {
+			cmd: logsCmd{
+				Output:    "default",
+				Follow:    true,
+				Lines:     10,
+				Grep:      []string{"line"},
+				GrepV:     []string{"error"},
+				JSONField: []string{"level=info"},
+			},
+			expectedLines: 10,
+		},
+		"invalid json field": {
+			cmd: logsCmd{
+				Output:    "default",
+				Lines:     10,
+				JSONField: []string{"level"},
+			},
+			expectedErrContains: "invalid value",
+		},
 		"from flag alone overrides since": {
 			cmd: logsCmd{
 				Output: "default",
@@ -143,3 +182,250 @@ func TestMatchLabels(t *testing.T) {
 		`{app="some-app",namespace="default"}`,
 	)
 }
+
+func TestOutputFile(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	apiClient := &api.Client{
+		Project: "default",
+		Log:     &log.Client{Client: log.NewFake(t, time.Now(), "first line", "second line")},
+	}
+
+	var buf bytes.Buffer
+	path := filepath.Join(t.TempDir(), "app.log")
+	cmd := logsCmd{Writer: format.NewWriter(&buf), Output: "default", Lines: 10, OutputFile: path}
+	is.NoError(cmd.Run(t.Context(), apiClient, ApplicationQuery("app-name", "app-ns")))
+
+	b, err := os.ReadFile(path)
+	is.NoError(err)
+	is.Equal(2, test.CountLines(string(b)))
+	is.Contains(string(b), "second line")
+	is.Empty(buf.String())
+}
+
+func TestPipeline(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		cmd         logsCmd
+		expected    string
+		expectedErr bool
+	}{
+		"no filters": {},
+		"plain line filters": {
+			cmd:      logsCmd{Grep: []string{"GET", `say "hi"`}, GrepV: []string{"healthz"}},
+			expected: ` |= "GET" |= "say \"hi\"" != "healthz"`,
+		},
+		"regex line filters": {
+			cmd:      logsCmd{Grep: []string{`status=5\d\d`}, GrepV: []string{"^DEBUG"}, Regex: true},
+			expected: ` |~ "status=5\\d\\d" !~ "^DEBUG"`,
+		},
+		"invalid regex": {
+			cmd:         logsCmd{Grep: []string{"(unclosed"}, Regex: true},
+			expectedErr: true,
+		},
+		"json fields": {
+			cmd:      logsCmd{Grep: []string{"request"}, JSONField: []string{"level=error", "request.method=GET"}},
+			expected: ` |= "request" | json | level="error" | request_method="GET"`,
+		},
+		"json field without value": {
+			cmd:         logsCmd{JSONField: []string{"level"}},
+			expectedErr: true,
+		},
+		"invalid json field name": {
+			cmd:         logsCmd{JSONField: []string{"log-level=info"}},
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			pipeline, err := tc.cmd.pipeline()
+			if tc.expectedErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+			is.Equal(tc.expected, pipeline)
+
+			_, err = syntax.ParseLogSelector(ApplicationQuery("app", "default")+pipeline, true)
+			is.NoError(err)
+		})
+	}
+}
+
+func TestApplicationQuery(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	cmd := applicationCmd{
+		resourceCmd:  resourceCmd{Name: "some-app"},
+		Type:         logTypeAll,
+		Replica:      "some-app-abc",
+		ScheduledJob: "cleanup",
+	}
+	is.Equal(
+		fmt.Sprintf(`{namespace="default",%s="some-app",%s="some-app-abc",%s="cleanup"}`,
+			apps.LogLabelApplication, apps.LogLabelReplica, apps.LogLabelScheduledJob),
+		cmd.query("default"),
+	)
+}
+
+func TestQueryString(t *testing.T) {
+	t.Parallel()
+
+	selector := ApplicationQuery("app", "default")
+	cases := map[string]struct {
+		cmd            logsCmd
+		expected       string
+		expectedMetric bool
+		expectedErr    bool
+	}{
+		"log query": {
+			cmd:      logsCmd{Grep: []string{"error"}},
+			expected: selector + ` |= "error"`,
+		},
+		"count by": {
+			cmd:            logsCmd{Grep: []string{"error"}, CountBy: 5 * time.Minute},
+			expected:       `sum(count_over_time(` + selector + ` |= "error" [5m]))`,
+			expectedMetric: true,
+		},
+		"raw log query": {
+			cmd:      logsCmd{Query: `{app="other"}`, GrepV: []string{"healthz"}},
+			expected: `{app="other"} != "healthz"`,
+		},
+		"raw log query with count by": {
+			cmd:            logsCmd{Query: `{app="other"}`, CountBy: time.Hour},
+			expected:       `sum(count_over_time({app="other"} [1h]))`,
+			expectedMetric: true,
+		},
+		"raw metric query": {
+			cmd:            logsCmd{Query: `sum by (level) (rate({app="other"} [5m]))`},
+			expected:       `sum by (level) (rate({app="other"} [5m]))`,
+			expectedMetric: true,
+		},
+		"raw metric query with filters": {
+			cmd:         logsCmd{Query: `rate({app="other"} [5m])`, Grep: []string{"error"}},
+			expectedErr: true,
+		},
+		"invalid raw query": {
+			cmd:         logsCmd{Query: `{app="other"`},
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			query, metric, err := tc.cmd.queryString(selector)
+			if tc.expectedErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+			is.Equal(tc.expected, query)
+			is.Equal(tc.expectedMetric, metric)
+			_, err = syntax.ParseExpr(query)
+			is.NoError(err)
+		})
+	}
+}
+
+func TestQueryWithName(t *testing.T) {
+	t.Parallel()
+
+	apiClient := test.SetupClient(t)
+	query := `{app="other"}`
+	for name, run := range map[string]func() error{
+		"application": func() error {
+			cmd := &applicationCmd{resourceCmd: resourceCmd{Name: "app"}, logsCmd: logsCmd{Query: query}}
+			return cmd.Run(t.Context(), apiClient)
+		},
+		"build": func() error {
+			cmd := &buildCmd{resourceCmd: resourceCmd{Name: "build"}, logsCmd: logsCmd{Query: query}}
+			return cmd.Run(t.Context(), apiClient)
+		},
+		"builds of application": func() error {
+			cmd := &buildCmd{ApplicationName: "app", logsCmd: logsCmd{Query: query}}
+			return cmd.Run(t.Context(), apiClient)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			err := run()
+			cliErr, ok := err.(*cli.Error)
+			is.True(ok, "expected a cli error, got %v", err)
+			is.Equal(cli.ExitUsageError, cliErr.ExitCode())
+		})
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	is.NoError((&logsCmd{Output: "sparkline"}).validateOutput(true))
+	is.NoError((&logsCmd{Output: "json", Follow: true}).validateOutput(false))
+	is.Error((&logsCmd{Output: "histogram"}).validateOutput(false))
+	is.Error((&logsCmd{Output: "default", Follow: true}).validateOutput(true))
+}
+
+func TestProjectQuery(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	is.Equal(
+		fmt.Sprintf(`{namespace="dev",%s!=""}`, apps.LogLabelApplication),
+		ProjectQuery("dev", logTypeAll),
+	)
+	is.Equal(
+		fmt.Sprintf(`{%s!="",namespace="dev",%s!=""}`, apps.LogLabelWorkerJob, apps.LogLabelApplication),
+		ProjectQuery("dev", logTypeWorkerJob),
+	)
+	_, err := syntax.ParseLogSelector(ProjectQuery("dev", logTypeApp), true)
+	is.NoError(err)
+}
+
+func TestProjectLogs(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	ts := time.Now().Add(-time.Minute)
+	_, logClient := log.NewTestServer(t,
+		log.Stream{
+			Labels:  map[string]string{"namespace": "dev", apps.LogLabelApplication: "web"},
+			Entries: []log.Entry{{Timestamp: ts, Line: "GET /"}},
+		},
+		log.Stream{
+			Labels:  map[string]string{"namespace": "dev", apps.LogLabelApplication: "worker", apps.LogLabelWorkerJob: "queue"},
+			Entries: []log.Entry{{Timestamp: ts.Add(time.Second), Line: "job done"}},
+		},
+		log.Stream{
+			Labels:  map[string]string{"namespace": "other", apps.LogLabelApplication: "web"},
+			Entries: []log.Entry{{Timestamp: ts, Line: "other project"}},
+		},
+	)
+
+	var buf bytes.Buffer
+	cmd := projectCmd{
+		logsCmd: logsCmd{Writer: format.NewWriter(&buf), Output: "default", Lines: 10, Since: time.Hour, NoLabels: true},
+		Type:    logTypeAll,
+	}
+	is.NoError(cmd.Run(t.Context(), &api.Client{Project: "dev", Log: logClient}))
+	is.Contains(buf.String(), "web | GET /")
+	is.Contains(buf.String(), "worker | job done")
+	is.NotContains(buf.String(), "other project")
+
+	buf.Reset()
+	cmd.Type = logTypeWorkerJob
+	is.NoError(cmd.Run(t.Context(), &api.Client{Project: "dev", Log: logClient}))
+	is.Equal(1, test.CountLines(buf.String()))
+}
@@ -0,0 +1,37 @@
+package logs
+
+import (
+	"context"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	"github.com/ninech/nctl/api"
+)
+
+type projectCmd struct {
+	logsCmd
+	Type appLogType `short:"t" help:"Which type of app logs to output. ${enum}" enum:"all,app,build,worker_job,deploy_job,scheduled_job" default:"all"`
+}
+
+// Run shows the logs of all applications in the project in a single stream,
+// with every line prefixed by the name of its application.
+func (cmd *projectCmd) Run(ctx context.Context, client *api.Client) error {
+	labels := applicationLabels
+	if cmd.Output == "json" {
+		// the prefix is not added to JSON lines, so we keep the label
+		// instead.
+		labels = append([]string{apps.LogLabelApplication}, labels...)
+	}
+	cmd.prefixLabel = apps.LogLabelApplication
+
+	return cmd.logsCmd.Run(ctx, client, ProjectQuery(client.Project, cmd.Type), labels...)
+}
+
+// ProjectQuery returns the query for the logs of the given type of all
+// applications in the project.
+func ProjectQuery(project string, logType appLogType) string {
+	return buildQuery(append(
+		logType.queryExpressions(),
+		inProject(project),
+		queryExpr(opNotEquals, apps.LogLabelApplication, ""),
+	)...)
+}
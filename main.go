@@ -23,13 +23,18 @@ import (
 	"github.com/ninech/nctl/copy"
 	"github.com/ninech/nctl/create"
 	"github.com/ninech/nctl/delete"
+	"github.com/ninech/nctl/describe"
+	"github.com/ninech/nctl/diff"
 	"github.com/ninech/nctl/edit"
 	"github.com/ninech/nctl/exec"
+	"github.com/ninech/nctl/export"
 	"github.com/ninech/nctl/get"
 	"github.com/ninech/nctl/internal/cli"
 	"github.com/ninech/nctl/internal/format"
 	"github.com/ninech/nctl/logs"
+	"github.com/ninech/nctl/portforward"
 	"github.com/ninech/nctl/predictor"
+	"github.com/ninech/nctl/run"
 	"github.com/ninech/nctl/update"
 	"github.com/posener/complete"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -48,18 +53,25 @@ type rootCommand struct {
 	flags
 
 	// Resource management
-	Get    get.Cmd    `cmd:"" help:"List resources across Nine APIs and watch them for changes." group:"verbs"`
-	Create create.Cmd `cmd:"" help:"Create resources from YAML or JSON files, or from resource-specific subcommands." group:"verbs"`
-	Apply  apply.Cmd  `cmd:"" help:"Apply resources declaratively from YAML or JSON files." group:"verbs"`
-	Update update.Cmd `cmd:"" help:"Update existing resources using resource-specific subcommands." group:"verbs"`
-	Delete delete.Cmd `cmd:"" help:"Delete resources by file or through resource-specific subcommands." group:"verbs"`
-	Edit   edit.Cmd   `cmd:"" help:"Edit supported resources interactively in your configured editor." group:"verbs"`
+	Get      get.Cmd      `cmd:"" help:"List resources across Nine APIs and watch them for changes." group:"verbs"`
+	Create   create.Cmd   `cmd:"" help:"Create resources from YAML or JSON files, or from resource-specific subcommands." group:"verbs"`
+	Apply    apply.Cmd    `cmd:"" help:"Apply resources declaratively from YAML or JSON files." group:"verbs"`
+	Diff     diff.Cmd     `cmd:"" help:"Show the changes applying YAML or JSON files would make to live resources." group:"verbs"`
+	Update   update.Cmd   `cmd:"" help:"Update existing resources using resource-specific subcommands." group:"verbs"`
+	Delete   delete.Cmd   `cmd:"" help:"Delete resources by file or through resource-specific subcommands." group:"verbs"`
+	Edit     edit.Cmd     `cmd:"" help:"Edit supported resources interactively in your configured editor." group:"verbs"`
+	Describe describe.Cmd `cmd:"" help:"Show a summary of a resource including its conditions, events and related resources." group:"verbs"`
+	Export   export.Cmd   `cmd:"" help:"Export the resources of a project to a directory of YAML manifests." group:"verbs"`
 
 	// Utility & interaction
 	Auth        auth.Cmd              `cmd:"" help:"Log in, switch organization or project context, and inspect your current session." group:"utils"`
 	Logs        logs.Cmd              `cmd:"" help:"Show logs for supported deplo.io resources such as applications and builds." group:"utils"`
 	Exec        exec.Cmd              `cmd:"" help:"Run a command or open a shell in a deplo.io application." group:"utils"`
 	Copy        copy.Cmd              `cmd:"" help:"Copy supported resources such as deplo.io applications." group:"utils"`
+	Run         run.Cmd               `cmd:"" help:"Run one-off commands in new containers of deplo.io applications." group:"utils"`
+	Cp          exec.CopyCmd          `cmd:"" name:"cp" help:"Copy files and directories to and from deplo.io application replicas." group:"utils"`
+	PortForward portforward.Cmd       `cmd:"" name:"port-forward" help:"Forward local ports to replicas of deplo.io applications." group:"utils"`
+	Proxy       exec.ProxyCmd         `cmd:"" help:"Forward local ports to storage instances such as PostgreSQL, MySQL and KeyValueStore." group:"utils"`
 	Completions completion.Completion `cmd:"" help:"Generate shell completion commands for your current shell." group:"utils"`
 }
 
@@ -122,6 +134,13 @@ func main() {
 			"edit-apps":    "Applications",
 			"edit-storage": "Databases & Object Storage",
 
+			"describe-access":        "Project & Access",
+			"describe-infra":         "Infrastructure",
+			"describe-apps":          "Applications",
+			"describe-storage":       "Databases & Object Storage",
+			"describe-network":       "Networking",
+			"describe-observability": "Observability",
+
 			"delete-general": "General",
 			"delete-access":  "Project & Access",
 			"delete-infra":   "Infrastructure",
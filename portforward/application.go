@@ -0,0 +1,232 @@
+package portforward
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/application"
+	"github.com/ninech/nctl/internal/format"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+type applicationCmd struct {
+	format.Writer    `kong:"-"`
+	Name             string        `arg:"" completion-predictor:"resource_name" help:"Name of the application."`
+	Ports            []string      `arg:"" help:"Ports to forward in the form LOCAL:REMOTE. A single port is used locally and remotely, :REMOTE forwards from a random local port."`
+	WorkerJob        string        `name:"worker-job" short:"w" help:"Forward to a replica of the worker job with the given name."`
+	Address          []string      `help:"Local addresses to listen on." default:"localhost"`
+	ReconnectTimeout time.Duration `help:"How long to wait for a ready replica when the current one is replaced, e.g. during a release." default:"2m"`
+
+	// forward forwards the ports to the replica until the connection is lost
+	// or the context is done. nil means using the Kubernetes port-forward
+	// subresource.
+	forward func(ctx context.Context, config *rest.Config, namespace, replica string, ports []string, ready func([]portforward.ForwardedPort)) error `kong:"-"`
+	// retryInterval overrides the defaultRetryInterval if set.
+	retryInterval time.Duration `kong:"-"`
+}
+
+// BeforeApply initializes Writer from Kong's bound [io.Writer].
+func (cmd *applicationCmd) BeforeApply(writer io.Writer) error {
+	return cmd.Writer.BeforeApply(writer)
+}
+
+// Help displays examples for the application port-forward command
+func (cmd applicationCmd) Help() string {
+	return `Examples:
+  # Forward local port 8080 to port 8080 of a replica of the application.
+  nctl port-forward app myapp 8080
+
+  # Forward local port 8080 to port 80 and 9090 to 9090 at the same time.
+  nctl port-forward app myapp 8080:80 9090
+
+  # Forward a random local port to port 8080 of the worker job "queue".
+  nctl port-forward app myapp :8080 --worker-job queue
+  `
+}
+
+// errConnect is returned if no connection to the replica can be established,
+// e.g. because it is terminating.
+var errConnect = errors.New("unable to connect to replica")
+
+// defaultRetryInterval is the time to wait between attempts to find and
+// connect to a ready replica when reconnecting.
+const defaultRetryInterval = 2 * time.Second
+
+func (cmd *applicationCmd) Run(ctx context.Context, client *api.Client) error {
+	ports, err := parsePorts(cmd.Ports)
+	if err != nil {
+		return err
+	}
+	replica, _, err := application.Replica(ctx, client, client.Name(cmd.Name), cmd.WorkerJob)
+	if err != nil {
+		return fmt.Errorf("error when searching for replica to connect: %w", err)
+	}
+	config, err := client.DeploioRuntimeConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("can not create deplo.io cluster rest config: %w", err)
+	}
+
+	if cmd.retryInterval == 0 {
+		cmd.retryInterval = defaultRetryInterval
+	}
+	forward := cmd.forward
+	if forward == nil {
+		forward = cmd.portForward
+	}
+	// deadline is the time until which reconnecting is retried. It is
+	// reset as soon as the ports are forwarded again.
+	var deadline time.Time
+	for {
+		err := forward(ctx, config, client.Project, replica, ports, func(forwarded []portforward.ForwardedPort) {
+			deadline = time.Time{}
+			// random local ports are kept when reconnecting, so
+			// clients can connect to the same port again.
+			ports = boundPorts(forwarded)
+			for _, p := range forwarded {
+				cmd.Successf("🔌", "forwarding from %s:%d to %s:%d (replica %s)", cmd.Address[0], p.Local, cmd.Name, p.Remote, replica)
+			}
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if deadline.IsZero() {
+			deadline = time.Now().Add(cmd.ReconnectTimeout)
+		}
+		switch {
+		case errors.Is(err, portforward.ErrLostConnectionToPod):
+			cmd.Warningf("lost connection to replica %s, reconnecting", replica)
+		case errors.Is(err, errConnect):
+			// the replica might be terminating, so we retry until
+			// another one is ready.
+			if time.Now().After(deadline) {
+				return fmt.Errorf("no connection to a replica within %s: %w", cmd.ReconnectTimeout, err)
+			}
+			cmd.Warningf("%s, retrying", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(cmd.retryInterval):
+			}
+		default:
+			return err
+		}
+
+		if replica, err = cmd.waitForReplica(ctx, client, deadline); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// waitForReplica waits until a ready replica is available or the deadline
+// is reached, as the replica we were connected to might have been replaced by
+// a new release.
+func (cmd *applicationCmd) waitForReplica(ctx context.Context, client *api.Client, deadline time.Time) (string, error) {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	for {
+		replica, _, err := application.Replica(ctx, client, client.Name(cmd.Name), cmd.WorkerJob)
+		if err == nil {
+			return replica, nil
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", fmt.Errorf("no ready replica found within %s: %w", cmd.ReconnectTimeout, err)
+			}
+			return "", nil
+		case <-time.After(cmd.retryInterval):
+		}
+	}
+}
+
+// boundPorts returns the port pairs of the forwarded ports, with the local
+// ports which were actually bound.
+func boundPorts(forwarded []portforward.ForwardedPort) []string {
+	ports := make([]string, 0, len(forwarded))
+	for _, p := range forwarded {
+		ports = append(ports, fmt.Sprintf("%d:%d", p.Local, p.Remote))
+	}
+	return ports
+}
+
+// portForward forwards the ports using the port-forward subresource of the
+// replica.
+func (cmd *applicationCmd) portForward(ctx context.Context, config *rest.Config, namespace, replica string, ports []string, ready func([]portforward.ForwardedPort)) error {
+	coreClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return err
+	}
+	url := coreClient.CoreV1().RESTClient().
+		Post().
+		Namespace(namespace).
+		Resource("pods").
+		Name(replica).
+		SubResource("portforward").
+		URL()
+	dialer := &recordingDialer{Dialer: spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)}
+
+	stopChan, readyChan := make(chan struct{}), make(chan struct{})
+	fw, err := portforward.NewOnAddresses(dialer, cmd.Address, ports, stopChan, readyChan, io.Discard, cmd.Writer)
+	if err != nil {
+		return err
+	}
+
+	errChan := make(chan error, 1)
+	go func() { errChan <- fw.ForwardPorts() }()
+
+	select {
+	case <-readyChan:
+		forwarded, err := fw.GetPorts()
+		if err != nil {
+			close(stopChan)
+			return err
+		}
+		ready(forwarded)
+	case err := <-errChan:
+		if dialer.failed {
+			return fmt.Errorf("%w %s: %w", errConnect, replica, err)
+		}
+		return err
+	case <-ctx.Done():
+		close(stopChan)
+		return <-errChan
+	}
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		close(stopChan)
+		return <-errChan
+	}
+}
+
+// recordingDialer records whether dialing the replica failed, to tell
+// connection errors apart from errors listening on the local ports.
+type recordingDialer struct {
+	httpstream.Dialer
+	failed bool
+}
+
+func (d *recordingDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	conn, protocol, err := d.Dialer.Dial(protocols...)
+	d.failed = err != nil
+	return conn, protocol, err
+}
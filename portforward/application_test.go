@@ -0,0 +1,315 @@
+package portforward
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	infrastructure "github.com/ninech/apis/infrastructure/v1alpha1"
+	meta "github.com/ninech/apis/meta/v1alpha1"
+	"github.com/ninech/nctl/internal/application"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+)
+
+const project = test.DefaultProject
+
+func TestParsePorts(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		pairs       []string
+		expected    []string
+		expectedErr bool
+	}{
+		"single port": {
+			pairs:    []string{"8080"},
+			expected: []string{"8080:8080"},
+		},
+		"pairs": {
+			pairs:    []string{"8080:80", "9090:9091"},
+			expected: []string{"8080:80", "9090:9091"},
+		},
+		"random local port": {
+			pairs:    []string{":80", "0:81"},
+			expected: []string{":80", "0:81"},
+		},
+		"zero remote port": {
+			pairs:       []string{"8080:0"},
+			expectedErr: true,
+		},
+		"missing remote port": {
+			pairs:       []string{"8080:"},
+			expectedErr: true,
+		},
+		"out of range": {
+			pairs:       []string{"65536"},
+			expectedErr: true,
+		},
+		"not a number": {
+			pairs:       []string{"http:80"},
+			expectedErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			ports, err := parsePorts(tc.pairs)
+			if tc.expectedErr {
+				is.Error(err)
+				return
+			}
+			is.NoError(err)
+			is.Equal(tc.expected, ports)
+		})
+	}
+}
+
+func TestApplicationReconnect(t *testing.T) {
+	t.Parallel()
+
+	const app = "myapp"
+	clusterData := &infrastructure.ClusterData{
+		ObjectMeta: metav1.ObjectMeta{Name: meta.ClusterDataDeploioName},
+	}
+
+	t.Run("reconnects to replica of new release", func(t *testing.T) {
+		t.Parallel()
+		is := require.New(t)
+
+		old := newRelease("old", app, "replica-1", time.Now().Add(-time.Hour))
+		apiClient := test.SetupClient(t,
+			test.WithKubeconfig(),
+			test.WithNameIndexFor(&apps.Release{}),
+			test.WithObjects(old, clusterData),
+			test.WithDefaultProject(project),
+		)
+
+		buf := &bytes.Buffer{}
+		replicas := []string{}
+		cmd := applicationCmd{
+			Writer:           format.NewWriter(buf),
+			Name:             app,
+			Ports:            []string{"8080:80"},
+			Address:          []string{"localhost"},
+			ReconnectTimeout: time.Minute,
+			forward: func(ctx context.Context, _ *rest.Config, namespace, replica string, _ []string, ready func([]portforward.ForwardedPort)) error {
+				is.Equal(project, namespace)
+				replicas = append(replicas, replica)
+				ready([]portforward.ForwardedPort{{Local: 8080, Remote: 80}})
+				if len(replicas) > 1 {
+					return nil
+				}
+				// a new release replaces the replica we are connected to.
+				is.NoError(apiClient.Delete(ctx, old))
+				is.NoError(apiClient.Create(ctx, newRelease("new", app, "replica-2", time.Now())))
+				return portforward.ErrLostConnectionToPod
+			},
+		}
+		is.NoError(cmd.Run(t.Context(), apiClient))
+		is.Equal([]string{"replica-1", "replica-2"}, replicas)
+		is.Contains(buf.String(), "lost connection to replica replica-1")
+		is.Contains(buf.String(), "forwarding from localhost:8080 to myapp:80 (replica replica-2)")
+	})
+
+	t.Run("no ready replica within timeout", func(t *testing.T) {
+		t.Parallel()
+		is := require.New(t)
+
+		old := newRelease("old", app, "replica-1", time.Now().Add(-time.Hour))
+		apiClient := test.SetupClient(t,
+			test.WithKubeconfig(),
+			test.WithNameIndexFor(&apps.Release{}),
+			test.WithObjects(old, clusterData),
+			test.WithDefaultProject(project),
+		)
+
+		cmd := applicationCmd{
+			Writer:           format.NewWriter(&bytes.Buffer{}),
+			Name:             app,
+			Ports:            []string{"8080"},
+			Address:          []string{"localhost"},
+			ReconnectTimeout: 10 * time.Millisecond,
+			forward: func(ctx context.Context, _ *rest.Config, _, _ string, _ []string, _ func([]portforward.ForwardedPort)) error {
+				is.NoError(apiClient.Delete(ctx, old))
+				return portforward.ErrLostConnectionToPod
+			},
+		}
+		is.ErrorContains(cmd.Run(t.Context(), apiClient), "no ready replica found")
+	})
+
+	t.Run("keeps random local ports", func(t *testing.T) {
+		t.Parallel()
+		is := require.New(t)
+
+		apiClient := test.SetupClient(t,
+			test.WithKubeconfig(),
+			test.WithNameIndexFor(&apps.Release{}),
+			test.WithObjects(newRelease("old", app, "replica-1", time.Now()), clusterData),
+			test.WithDefaultProject(project),
+		)
+
+		requested := [][]string{}
+		cmd := applicationCmd{
+			Writer:           format.NewWriter(&bytes.Buffer{}),
+			Name:             app,
+			Ports:            []string{":80", "9090:90"},
+			Address:          []string{"localhost"},
+			ReconnectTimeout: time.Minute,
+			forward: func(_ context.Context, _ *rest.Config, _, _ string, ports []string, ready func([]portforward.ForwardedPort)) error {
+				requested = append(requested, ports)
+				ready([]portforward.ForwardedPort{{Local: 43210, Remote: 80}, {Local: 9090, Remote: 90}})
+				if len(requested) > 1 {
+					return nil
+				}
+				return portforward.ErrLostConnectionToPod
+			},
+		}
+		is.NoError(cmd.Run(t.Context(), apiClient))
+		is.Equal([][]string{{":80", "9090:90"}, {"43210:80", "9090:90"}}, requested)
+	})
+
+	t.Run("retries when connecting fails", func(t *testing.T) {
+		t.Parallel()
+		is := require.New(t)
+
+		apiClient := test.SetupClient(t,
+			test.WithKubeconfig(),
+			test.WithNameIndexFor(&apps.Release{}),
+			test.WithObjects(newRelease("old", app, "replica-1", time.Now()), clusterData),
+			test.WithDefaultProject(project),
+		)
+
+		buf := &bytes.Buffer{}
+		attempts := 0
+		cmd := applicationCmd{
+			Writer:           format.NewWriter(buf),
+			Name:             app,
+			Ports:            []string{"8080"},
+			Address:          []string{"localhost"},
+			ReconnectTimeout: time.Minute,
+			retryInterval:    time.Millisecond,
+			forward: func(_ context.Context, _ *rest.Config, _, replica string, _ []string, ready func([]portforward.ForwardedPort)) error {
+				attempts++
+				if attempts < 3 {
+					// the replica is terminating and does not accept
+					// connections anymore.
+					return fmt.Errorf("%w %s: error upgrading connection", errConnect, replica)
+				}
+				ready([]portforward.ForwardedPort{{Local: 8080, Remote: 8080}})
+				return nil
+			},
+		}
+		is.NoError(cmd.Run(t.Context(), apiClient))
+		is.Equal(3, attempts)
+		is.Contains(buf.String(), "unable to connect to replica replica-1")
+		is.Contains(buf.String(), "forwarding from localhost:8080")
+	})
+
+	t.Run("connecting fails until timeout", func(t *testing.T) {
+		t.Parallel()
+		is := require.New(t)
+
+		apiClient := test.SetupClient(t,
+			test.WithKubeconfig(),
+			test.WithNameIndexFor(&apps.Release{}),
+			test.WithObjects(newRelease("old", app, "replica-1", time.Now()), clusterData),
+			test.WithDefaultProject(project),
+		)
+
+		cmd := applicationCmd{
+			Writer:           format.NewWriter(&bytes.Buffer{}),
+			Name:             app,
+			Ports:            []string{"8080"},
+			Address:          []string{"localhost"},
+			ReconnectTimeout: 10 * time.Millisecond,
+			retryInterval:    time.Millisecond,
+			forward: func(_ context.Context, _ *rest.Config, _, replica string, _ []string, _ func([]portforward.ForwardedPort)) error {
+				return fmt.Errorf("%w %s: error upgrading connection", errConnect, replica)
+			},
+		}
+		err := cmd.Run(t.Context(), apiClient)
+		is.ErrorIs(err, errConnect)
+		is.ErrorContains(err, "no connection to a replica within")
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		t.Parallel()
+		is := require.New(t)
+
+		apiClient := test.SetupClient(t,
+			test.WithKubeconfig(),
+			test.WithNameIndexFor(&apps.Release{}),
+			test.WithObjects(newRelease("old", app, "replica-1", time.Now()), clusterData),
+			test.WithDefaultProject(project),
+		)
+
+		attempts := 0
+		cmd := applicationCmd{
+			Writer:           format.NewWriter(&bytes.Buffer{}),
+			Name:             app,
+			Ports:            []string{"8080"},
+			Address:          []string{"localhost"},
+			ReconnectTimeout: time.Minute,
+			forward: func(context.Context, *rest.Config, string, string, []string, func([]portforward.ForwardedPort)) error {
+				attempts++
+				return errors.New("unable to listen on any of the requested ports")
+			},
+		}
+		is.ErrorContains(cmd.Run(t.Context(), apiClient), "unable to listen")
+		is.Equal(1, attempts)
+	})
+
+	t.Run("stops when context is done", func(t *testing.T) {
+		t.Parallel()
+		is := require.New(t)
+
+		apiClient := test.SetupClient(t,
+			test.WithKubeconfig(),
+			test.WithNameIndexFor(&apps.Release{}),
+			test.WithObjects(newRelease("old", app, "replica-1", time.Now()), clusterData),
+			test.WithDefaultProject(project),
+		)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cmd := applicationCmd{
+			Writer:  format.NewWriter(&bytes.Buffer{}),
+			Name:    app,
+			Ports:   []string{"8080"},
+			Address: []string{"localhost"},
+			forward: func(ctx context.Context, _ *rest.Config, _, _ string, _ []string, _ func([]portforward.ForwardedPort)) error {
+				cancel()
+				return portforward.ErrLostConnectionToPod
+			},
+		}
+		is.NoError(cmd.Run(ctx, apiClient))
+	})
+}
+
+func newRelease(name, app, replica string, created time.Time) *apps.Release {
+	return &apps.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: project,
+			Labels:    map[string]string{application.ApplicationNameLabel: app},
+		},
+		CreationTimestampNano: created.UnixNano(),
+		Status: apps.ReleaseStatus{
+			AtProvider: apps.ReleaseObservation{
+				ReleaseStatus: apps.ReleaseProcessStatusAvailable,
+				ReplicaObservation: []apps.ReplicaObservation{
+					{Status: apps.ReplicaStatusReady, ReplicaName: replica},
+				},
+			},
+		},
+	}
+}
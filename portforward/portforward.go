@@ -0,0 +1,44 @@
+// Package portforward provides the implementation for the port-forward
+// command, which forwards local ports to deplo.io resources.
+package portforward
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ninech/nctl/internal/cli"
+)
+
+// Cmd holds all port-forward sub-commands.
+type Cmd struct {
+	Application applicationCmd `cmd:"" group:"deplo.io" aliases:"app" name:"application" help:"Forward local ports to a replica of a deplo.io application."`
+}
+
+// parsePorts validates the port pairs in the form LOCAL:REMOTE. A single port
+// is used as local and remote port, an empty or zero local port lets the
+// system choose a free one.
+func parsePorts(pairs []string) ([]string, error) {
+	ports := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		local, remote, found := strings.Cut(pair, ":")
+		if !found {
+			remote = local
+		}
+		if (local != "" && !validPort(local, true)) || !validPort(remote, false) {
+			return nil, cli.ErrorWithContext(fmt.Errorf("invalid port pair %q", pair)).
+				WithExitCode(cli.ExitUsageError).
+				WithSuggestions("Specify ports as LOCAL:REMOTE, e.g. 8080:80, or as :REMOTE to use a random local port")
+		}
+		ports = append(ports, local+":"+remote)
+	}
+	return ports, nil
+}
+
+func validPort(port string, allowZero bool) bool {
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return false
+	}
+	return p != 0 || allowZero
+}
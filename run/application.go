@@ -0,0 +1,278 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	"github.com/ninech/nctl/api"
+	"github.com/ninech/nctl/internal/application"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	containerName = "run"
+	// appAnnotation is set on the process to the name of the application.
+	appAnnotation = "run.nctl.nine.ch/application"
+	// appLabel is set on the process to the name of the application. The
+	// labels of the replicas are not set, so the process is not selected
+	// by the services and controllers of the application.
+	appLabel = "run.nctl.nine.ch/application"
+)
+
+var (
+	// pollInterval is the interval in which the state of the process is
+	// checked while waiting for it.
+	pollInterval = time.Second
+	// terminationTimeout is how long to wait for the terminated state of the
+	// process after its logs ended.
+	terminationTimeout = time.Minute
+	// failedReasons are reasons of a waiting container which will not
+	// resolve by waiting longer.
+	failedReasons = []string{"ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull", "CreateContainerConfigError", "CreateContainerError"}
+)
+
+type applicationCmd struct {
+	format.Writer `kong:"-"`
+	Name          string        `arg:"" completion-predictor:"resource_name" help:"Name of the application."`
+	Command       []string      `arg:"" help:"Command to run."`
+	StartTimeout  time.Duration `help:"How long to wait for the process to start, e.g. while its image is pulled." default:"5m"`
+
+	// runtimeClient and streamLogs access the deplo.io runtime cluster. nil
+	// means connecting to it with the API client.
+	runtimeClient runtimeclient.Client                                                                    `kong:"-"`
+	streamLogs    func(ctx context.Context, namespace, pod string, since *metav1.Time, w io.Writer) error `kong:"-"`
+}
+
+// BeforeApply initializes Writer from Kong's bound [io.Writer].
+func (cmd *applicationCmd) BeforeApply(writer io.Writer) error {
+	return cmd.Writer.BeforeApply(writer)
+}
+
+// Help displays examples for the application run command
+func (cmd applicationCmd) Help() string {
+	return `The command runs in a new container with the image, environment variables,
+size and settings of a replica of the latest available release. Unlike
+"nctl exec", it does not run in a replica serving traffic, so it is not
+stopped when the replicas are replaced. The container is removed once the
+command is done and nctl exits with the exit code of the command.
+
+Examples:
+  # Run the database migrations of a Rails application.
+  nctl run app myapp -- rake db:migrate
+
+  # Run a shell script with arguments.
+  nctl run app myapp -- sh -c 'echo $DATABASE_URL'
+  `
+}
+
+func (cmd *applicationCmd) Run(ctx context.Context, client *api.Client) error {
+	replicaName, release, err := application.Replica(ctx, client, client.Name(cmd.Name), "")
+	if err != nil {
+		return err
+	}
+	if err := cmd.setupRuntime(ctx, client); err != nil {
+		return err
+	}
+	replica := &corev1.Pod{}
+	if err := cmd.runtimeClient.Get(ctx, types.NamespacedName{Namespace: client.Project, Name: replicaName}, replica); err != nil {
+		return fmt.Errorf("unable to get replica %s: %w", replicaName, err)
+	}
+
+	pod, err := oneOffPod(replica, release, cmd.Name, cmd.Command)
+	if err != nil {
+		return err
+	}
+	if err := cmd.runtimeClient.Create(ctx, pod); err != nil {
+		return fmt.Errorf("unable to start process: %w", err)
+	}
+	// the process is removed when it is done or nctl is interrupted.
+	defer func() {
+		if err := cmd.runtimeClient.Delete(context.WithoutCancel(ctx), pod); err != nil && !kerrors.IsNotFound(err) {
+			cmd.Warningf("unable to remove process %s: %s", pod.Name, err)
+		}
+	}()
+
+	cmd.Infof("🚀", "starting process %s with release %s", pod.Name, release.Name)
+	if err := cmd.waitForPod(ctx, pod, cmd.StartTimeout, started); err != nil {
+		return fmt.Errorf("process %s did not start: %w", pod.Name, err)
+	}
+	if err := cmd.followLogs(ctx, pod); err != nil {
+		return fmt.Errorf("unable to stream logs of process %s: %w", pod.Name, err)
+	}
+	// the logs end when the container terminates, but its state might
+	// not be updated yet.
+	if err := cmd.waitForPod(ctx, pod, terminationTimeout, terminated); err != nil {
+		return fmt.Errorf("unable to get exit code of process %s: %w", pod.Name, err)
+	}
+
+	if code := containerState(pod).Terminated.ExitCode; code != 0 {
+		return cli.ErrorWithContext(fmt.Errorf("process %s exited with code %d", pod.Name, code)).
+			WithExitCode(int(code))
+	}
+	cmd.Successf("🏁", "process %s completed", pod.Name)
+	return nil
+}
+
+// setupRuntime connects to the deplo.io runtime cluster unless the clients
+// have already been set.
+func (cmd *applicationCmd) setupRuntime(ctx context.Context, client *api.Client) error {
+	if cmd.runtimeClient == nil {
+		scheme := runtime.NewScheme()
+		if err := corev1.AddToScheme(scheme); err != nil {
+			return err
+		}
+		runtimeClient, err := client.DeploioRuntimeClient(ctx, scheme)
+		if err != nil {
+			return fmt.Errorf("can not create deplo.io cluster client: %w", err)
+		}
+		cmd.runtimeClient = runtimeClient
+	}
+	if cmd.streamLogs != nil {
+		return nil
+	}
+
+	config, err := client.DeploioRuntimeConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("can not create deplo.io cluster rest config: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	cmd.streamLogs = func(ctx context.Context, namespace, pod string, since *metav1.Time, w io.Writer) error {
+		stream, err := clientset.CoreV1().Pods(namespace).
+			GetLogs(pod, &corev1.PodLogOptions{Container: containerName, Follow: true, SinceTime: since}).
+			Stream(ctx)
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+		_, err = io.Copy(w, stream)
+		return err
+	}
+	return nil
+}
+
+// followLogs streams the logs of the process until its container has
+// terminated. If the stream breaks while the process is still running, the
+// logs are streamed again from the time the stream broke.
+func (cmd *applicationCmd) followLogs(ctx context.Context, pod *corev1.Pod) error {
+	var since *metav1.Time
+	for {
+		err := cmd.streamLogs(ctx, pod.Namespace, pod.Name, since, cmd.Writer)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		broken := metav1.Now()
+		if getErr := cmd.runtimeClient.Get(ctx, api.ObjectName(pod), pod); getErr != nil {
+			return err
+		}
+		if done, _ := terminated(pod); done {
+			return nil
+		}
+
+		cmd.Warningf("log stream of process %s broke, reconnecting: %s", pod.Name, err)
+		since = &broken
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// waitForPod polls the pod until the condition is true, it fails or the
+// timeout is reached.
+func (cmd *applicationCmd) waitForPod(ctx context.Context, pod *corev1.Pod, timeout time.Duration, condition func(*corev1.Pod) (bool, error)) error {
+	return wait.PollUntilContextTimeout(ctx, pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		if err := cmd.runtimeClient.Get(ctx, api.ObjectName(pod), pod); err != nil {
+			return false, err
+		}
+		return condition(pod)
+	})
+}
+
+// oneOffPod returns a pod running the command, based on the replica of the
+// release. The pod gets the settings of the replica like its image pull
+// secrets, service account and security context, but does not serve traffic
+// and is not managed by the controllers of the replicas.
+func oneOffPod(replica *corev1.Pod, release *apps.Release, app string, command []string) (*corev1.Pod, error) {
+	if len(replica.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("replica %s has no containers", replica.Name)
+	}
+	if !release.Spec.ForProvider.DockerfileBuild {
+		command = append([]string{application.BuildpackEntrypoint}, command...)
+	}
+
+	spec := replica.Spec.DeepCopy()
+	// the first container of the replica runs the application.
+	container := spec.Containers[0]
+	container.Name = containerName
+	container.Command = command
+	container.Args = nil
+	// the process does not serve the probes of the application, so it
+	// would be restarted or never become ready.
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	spec.Containers = []corev1.Container{container}
+	spec.RestartPolicy = corev1.RestartPolicyNever
+	// the replica was scheduled to a node, the process is scheduled anew.
+	spec.NodeName = ""
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: app + "-run-",
+			Namespace:    replica.Namespace,
+			Labels:       map[string]string{appLabel: app},
+			Annotations: map[string]string{
+				cli.ManagedByAnnotation: cli.Name,
+				appAnnotation:           app,
+			},
+		},
+		Spec: *spec,
+	}, nil
+}
+
+// containerState returns the state of the container running the command.
+func containerState(pod *corev1.Pod) corev1.ContainerState {
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.Name == containerName {
+			return s.State
+		}
+	}
+	return corev1.ContainerState{}
+}
+
+// started returns true once the container is running or has terminated. It
+// fails if the container can not be started.
+func started(pod *corev1.Pod) (bool, error) {
+	state := containerState(pod)
+	if state.Running != nil || state.Terminated != nil {
+		return true, nil
+	}
+	if state.Waiting != nil && slices.Contains(failedReasons, state.Waiting.Reason) {
+		return false, fmt.Errorf("%s: %s", state.Waiting.Reason, state.Waiting.Message)
+	}
+	if pod.Status.Phase == corev1.PodFailed {
+		return false, fmt.Errorf("%s: %s", pod.Status.Reason, pod.Status.Message)
+	}
+	return false, nil
+}
+
+func terminated(pod *corev1.Pod) (bool, error) {
+	return containerState(pod).Terminated != nil, nil
+}
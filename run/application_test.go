@@ -0,0 +1,256 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	apps "github.com/ninech/apis/apps/v1alpha1"
+	meta "github.com/ninech/apis/meta/v1alpha1"
+	"github.com/ninech/nctl/internal/application"
+	"github.com/ninech/nctl/internal/cli"
+	"github.com/ninech/nctl/internal/format"
+	"github.com/ninech/nctl/internal/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const project = test.DefaultProject
+
+func TestApplication(t *testing.T) {
+	t.Parallel()
+
+	const app = "myapp"
+	for name, tc := range map[string]struct {
+		dockerfileBuild bool
+		waiting         *corev1.ContainerStateWaiting
+		exitCode        int32
+		streamErrors    int
+		expectedCommand []string
+		expectedErr     bool
+		expectedCode    int
+	}{
+		"buildpack app": {
+			expectedCommand: []string{application.BuildpackEntrypoint, "rake", "db:migrate"},
+		},
+		"dockerfile app": {
+			dockerfileBuild: true,
+			expectedCommand: []string{"rake", "db:migrate"},
+		},
+		"command fails": {
+			exitCode:     3,
+			expectedErr:  true,
+			expectedCode: 3,
+		},
+		"log stream breaks while running": {
+			streamErrors: 1,
+		},
+		"image can not be pulled": {
+			waiting:     &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "not found"},
+			expectedErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := require.New(t)
+
+			release := &apps.Release{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myapp-release",
+					Namespace: project,
+					Labels:    map[string]string{application.ApplicationNameLabel: app},
+				},
+				CreationTimestampNano: time.Now().UnixNano(),
+				Spec: apps.ReleaseSpec{
+					ForProvider: apps.ReleaseParameters{
+						DockerfileBuild: tc.dockerfileBuild,
+						Image: meta.Image{
+							Registry:   "registry.example.org",
+							Repository: "myapp",
+							Tag:        "v1",
+						},
+						Configuration: apps.Config{
+							Size: test.AppMicro,
+							Env:  apps.EnvVars{{Name: "RAILS_ENV", Value: "production"}},
+						}.WithOrigin(apps.ConfigOriginApplication),
+					},
+				},
+				Status: apps.ReleaseStatus{
+					AtProvider: apps.ReleaseObservation{
+						ReleaseStatus: apps.ReleaseProcessStatusAvailable,
+						ReplicaObservation: []apps.ReplicaObservation{
+							{Status: apps.ReplicaStatusReady, ReplicaName: "myapp-replica"},
+						},
+					},
+				},
+			}
+			resources := apps.AppResources[test.AppMicro]
+			replica := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myapp-replica",
+					Namespace: project,
+					Labels:    map[string]string{"app": app, "pod-template-hash": "abc123"},
+				},
+				Spec: corev1.PodSpec{
+					NodeName:           "node-1",
+					ServiceAccountName: "myapp",
+					ImagePullSecrets:   []corev1.LocalObjectReference{{Name: "registry"}},
+					SecurityContext:    &corev1.PodSecurityContext{RunAsNonRoot: ptr.To(true)},
+					Containers: []corev1.Container{{
+						Name:           "app",
+						Image:          "registry.example.org/myapp:v1",
+						Args:           []string{"web"},
+						Env:            []corev1.EnvVar{{Name: "RAILS_ENV", Value: "production"}},
+						EnvFrom:        []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "myapp-env"}}}},
+						Resources:      corev1.ResourceRequirements{Limits: resources, Requests: resources},
+						LivenessProbe:  &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz"}}},
+						ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz"}}},
+					}},
+				},
+			}
+			apiClient := test.SetupClient(t,
+				test.WithNameIndexFor(&apps.Release{}),
+				test.WithObjects(release),
+				test.WithDefaultProject(project),
+			)
+
+			// the fake runtime cluster starts the container of every
+			// created pod.
+			scheme := runtime.NewScheme()
+			is.NoError(corev1.AddToScheme(scheme))
+			created := &corev1.Pod{}
+			runtimeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(replica).WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c runtimeclient.WithWatch, obj runtimeclient.Object, opts ...runtimeclient.CreateOption) error {
+					if err := c.Create(ctx, obj, opts...); err != nil {
+						return err
+					}
+					pod := obj.(*corev1.Pod)
+					state := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+					if tc.waiting != nil {
+						state = corev1.ContainerState{Waiting: tc.waiting}
+					}
+					pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: containerName, State: state}}
+					pod.DeepCopyInto(created)
+					return c.Status().Update(ctx, pod)
+				},
+			}).Build()
+
+			buf := &bytes.Buffer{}
+			streams := 0
+			cmd := applicationCmd{
+				Writer:        format.NewWriter(buf),
+				Name:          app,
+				Command:       []string{"rake", "db:migrate"},
+				StartTimeout:  time.Minute,
+				runtimeClient: runtimeClient,
+				streamLogs: func(ctx context.Context, namespace, name string, since *metav1.Time, w io.Writer) error {
+					is.Equal(project, namespace)
+					streams++
+					if streams <= tc.streamErrors {
+						is.Nil(since)
+						return errors.New("stream reset")
+					}
+					// the logs are streamed again from the time
+					// the stream broke.
+					is.Equal(tc.streamErrors > 0, since != nil)
+					fmt.Fprintln(w, "migrating")
+
+					pod := &corev1.Pod{}
+					if err := runtimeClient.Get(ctx, runtimeclient.ObjectKey{Namespace: namespace, Name: name}, pod); err != nil {
+						return err
+					}
+					pod.Status.ContainerStatuses[0].State = corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: tc.exitCode},
+					}
+					return runtimeClient.Status().Update(ctx, pod)
+				},
+			}
+
+			err := cmd.Run(t.Context(), apiClient)
+			if tc.expectedErr {
+				is.Error(err)
+				if tc.expectedCode != 0 {
+					cliErr, ok := errors.AsType[*cli.Error](err)
+					is.True(ok)
+					is.Equal(tc.expectedCode, cliErr.ExitCode())
+				}
+			} else {
+				is.NoError(err)
+				is.Contains(buf.String(), "migrating")
+				is.Contains(buf.String(), "completed")
+				is.Equal(tc.streamErrors+1, streams)
+			}
+
+			// the process is removed in any case.
+			pods := &corev1.PodList{}
+			is.NoError(runtimeClient.List(t.Context(), pods))
+			is.Len(pods.Items, 1)
+			is.Equal(replica.Name, pods.Items[0].Name)
+
+			// the process gets the settings of the replica.
+			is.Equal(project, created.Namespace)
+			is.Equal(map[string]string{appLabel: app}, created.Labels)
+			is.Equal(replica.Spec.ServiceAccountName, created.Spec.ServiceAccountName)
+			is.Equal(replica.Spec.ImagePullSecrets, created.Spec.ImagePullSecrets)
+			is.Equal(replica.Spec.SecurityContext, created.Spec.SecurityContext)
+			is.Empty(created.Spec.NodeName)
+			is.Equal(corev1.RestartPolicyNever, created.Spec.RestartPolicy)
+			is.Len(created.Spec.Containers, 1)
+			container := created.Spec.Containers[0]
+			is.Equal(containerName, container.Name)
+			is.Equal("registry.example.org/myapp:v1", container.Image)
+			is.Equal(replica.Spec.Containers[0].Env, container.Env)
+			is.Equal(replica.Spec.Containers[0].EnvFrom, container.EnvFrom)
+			is.Equal(resources, container.Resources.Limits)
+			is.Nil(container.Args)
+			is.Nil(container.LivenessProbe)
+			is.Nil(container.ReadinessProbe)
+			if tc.expectedCommand != nil {
+				is.Equal(tc.expectedCommand, container.Command)
+			}
+		})
+	}
+}
+
+func TestOneOffPodNotServing(t *testing.T) {
+	t.Parallel()
+	is := require.New(t)
+
+	// the service of the application selects its replicas by these
+	// labels.
+	serving := map[string]string{"app": "myapp", "release": "myapp-release"}
+	replica := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myapp-replica",
+			Namespace: project,
+			Labels:    map[string]string{"app": "myapp", "release": "myapp-release", "pod-template-hash": "abc123"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:           "app",
+				Image:          "registry.example.org/myapp:v1",
+				ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz"}}},
+			}},
+		},
+	}
+	pod, err := oneOffPod(replica, &apps.Release{}, "myapp", []string{"rake", "db:migrate"})
+	is.NoError(err)
+
+	is.False(labels.SelectorFromSet(serving).Matches(labels.Set(pod.Labels)), "process is selected by %v", pod.Labels)
+	for key := range serving {
+		is.NotContains(pod.Labels, key)
+	}
+	// the process does not serve the readiness probe of the application.
+	is.Nil(pod.Spec.Containers[0].ReadinessProbe)
+}
@@ -0,0 +1,8 @@
+// Package run provides the implementation for the run command, which runs
+// one-off processes of deplo.io resources.
+package run
+
+// Cmd holds all run sub-commands.
+type Cmd struct {
+	Application applicationCmd `cmd:"" group:"deplo.io" aliases:"app" name:"application" help:"Run a one-off command in a new container of a deplo.io application."`
+}